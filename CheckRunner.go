@@ -0,0 +1,221 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckWorkers = 4
+	defaultCheckTimeout = 10 * time.Minute
+)
+
+// checkGraph is the order the objects have to be checked in.  dependents[i] are the objects
+// waiting on object i, and waiting[i] is how many objects object i still waits on.
+type checkGraph struct {
+	names      []string
+	dependents [][]int
+	waiting    []int
+}
+
+// newCheckGraph resolves the Dependencies of every object.  A dependency on an object which is
+// not being checked, for example the OpenShift cluster without a kubeconfig, is ignored.
+func newCheckGraph(robjs []RunnableObject) (*checkGraph, error) {
+	var (
+		graph = &checkGraph{
+			names:      make([]string, len(robjs)),
+			dependents: make([][]int, len(robjs)),
+			waiting:    make([]int, len(robjs)),
+		}
+		indexes = make(map[string]int)
+		err     error
+	)
+
+	for i, robj := range robjs {
+		graph.names[i], err = robj.ObjectName()
+		if err != nil {
+			return nil, err
+		}
+		indexes[graph.names[i]] = i
+	}
+
+	for i, robj := range robjs {
+		for _, dependency := range robj.Dependencies() {
+			j, ok := indexes[dependency]
+			if !ok {
+				log.Debugf("newCheckGraph: %s depends on %s which is not being checked", graph.names[i], dependency)
+				continue
+			}
+			if i == j {
+				return nil, fmt.Errorf("Error: %s depends on itself", graph.names[i])
+			}
+
+			graph.dependents[j] = append(graph.dependents[j], i)
+			graph.waiting[i]++
+		}
+	}
+
+	err = graph.checkCycles()
+	if err != nil {
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// checkCycles walks the graph the same way runChecks will, and fails if some objects can never start.
+func (graph *checkGraph) checkCycles() error {
+	var (
+		waiting = append([]int{}, graph.waiting...)
+		ready   []int
+		stuck   []string
+		started = 0
+	)
+
+	for i := range waiting {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		started++
+
+		for _, j := range graph.dependents[i] {
+			waiting[j]--
+			if waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if started == len(waiting) {
+		return nil
+	}
+
+	for i := range waiting {
+		if waiting[i] > 0 {
+			stuck = append(stuck, graph.names[i])
+		}
+	}
+
+	return fmt.Errorf("Error: The dependencies between %s form a cycle", strings.Join(stuck, ", "))
+}
+
+// runChecks asks every object for its result on a pool of workers.  An object only starts once
+// the objects it depends on have finished, and an object which takes longer than timeout is
+// Unknown.  The reports are in the same order as robjs, whatever order the objects finish in.
+func runChecks(robjs []RunnableObject, workers int, timeout time.Duration) ([]ObjectReport, error) {
+	var (
+		graph    *checkGraph
+		reports  = make([]ObjectReport, len(robjs))
+		ready    = make(chan int, len(robjs))
+		finished = make(chan int)
+		err      error
+	)
+
+	graph, err = newCheckGraph(robjs)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range ready {
+				reports[i] = runCheck(robjs[i], graph.names[i], timeout)
+				finished <- i
+			}
+		}()
+	}
+
+	// The objects are sorted by priority, so queue them in that order.
+	for i := range robjs {
+		if graph.waiting[i] == 0 {
+			ready <- i
+		}
+	}
+
+	for done := 0; done < len(robjs); done++ {
+		i := <-finished
+		log.Debugf("runChecks: %s is %s", graph.names[i], reports[i].Status)
+
+		for _, j := range graph.dependents[i] {
+			graph.waiting[j]--
+			if graph.waiting[j] == 0 {
+				ready <- j
+			}
+		}
+	}
+	close(ready)
+
+	return reports, nil
+}
+
+// checksInFlight are the objects whose ClusterStatus has not returned yet.  A check which ignores
+// its context can outlive its timeout, and it is not started again until it returns.
+var checksInFlight sync.Map
+
+// runCheck asks one object for its result.  The context of a check which does not return in time
+// is cancelled.
+func runCheck(robj RunnableObject, name string, timeout time.Duration) ObjectReport {
+	var (
+		resultChan  = make(chan CheckResult, 1)
+		result      CheckResult
+		start       = time.Now()
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	)
+
+	defer cancel()
+
+	if _, running := checksInFlight.LoadOrStore(robj, true); running {
+		result = unknownCheckResult(name, "The previous %s check is still running", name)
+		return newObjectReport(name, result, 0)
+	}
+
+	fmt.Fprintf(os.Stderr, "Checking the %s...\n", name)
+
+	go func() {
+		defer checksInFlight.Delete(robj)
+		defer func() {
+			if r := recover(); r != nil {
+				resultChan <- unknownCheckResult(name, "The %s check crashed: %v", name, r)
+			}
+		}()
+
+		resultChan <- robj.ClusterStatus(ctx)
+	}()
+
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		result = unknownCheckResult(name, "The %s check did not finish within %v", name, timeout)
+	}
+
+	fmt.Fprintf(os.Stderr, "Checked the %s in %v\n", name, time.Since(start).Round(time.Millisecond))
+
+	return newObjectReport(name, result, time.Since(start).Seconds())
+}
@@ -26,17 +26,26 @@ import (
 
 func checkAliveCommand(checkAliveFlags *flag.FlagSet, args []string) error {
 	var (
-		out            io.Writer
-		ptrServerIP    *string
-		ptrShouldDebug *string
-		err            error
+		out              io.Writer
+		ptrServerIP      *string
+		ptrCertDirectory *string
+		ptrShouldDebug   *string
+		err              error
 	)
 
 	ptrServerIP = checkAliveFlags.String("serverIP", "", "The IP address of the server to send the command to")
+	ptrCertDirectory = addCertDirectoryFlag(checkAliveFlags)
 	ptrShouldDebug = checkAliveFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(checkAliveFlags)
+
 	checkAliveFlags.Parse(args)
 
+	err = applyProfile(checkAliveFlags)
+	if err != nil {
+		return err
+	}
+
 	if ptrServerIP == nil || *ptrServerIP == "" {
 		return fmt.Errorf("Error: --serverIP not specified")
 	}
@@ -63,7 +72,7 @@ func checkAliveCommand(checkAliveFlags *flag.FlagSet, args []string) error {
 
 	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
 
-	err = sendCheckAlive(*ptrServerIP)
+	err = sendCheckAlive(*ptrServerIP, *ptrCertDirectory)
 
 	return err
 }
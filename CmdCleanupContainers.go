@@ -0,0 +1,210 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/containers"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func cleanupContainersCommand(cleanupContainersFlags *flag.FlagSet, args []string) error {
+	var (
+		out                io.Writer
+		ptrCloud           *string
+		ptrInfraID         *string
+		ptrSuffix          *string
+		ptrOlderThan       *string
+		ptrBastionMetadata *string
+		ptrDryRun          *string
+		ptrShouldDebug     *string
+		dryRun             = false
+		olderThan          time.Duration
+		liveInfraIDs       = sets.Set[string]{}
+		ctx                context.Context
+		cancel             context.CancelFunc
+		allContainers      []containers.Container
+		err                error
+	)
+
+	ptrCloud = cleanupContainersFlags.String("cloud", "", "The cloud to use in clouds.yaml")
+	ptrInfraID = cleanupContainersFlags.String("infraID", "", "Only delete containers which start with this infraID")
+	ptrSuffix = cleanupContainersFlags.String("suffix", "-ignition", "Only delete containers which end with this suffix")
+	ptrOlderThan = cleanupContainersFlags.String("olderThan", "0s", "Only delete containers older than this duration (e.g. 24h)")
+	ptrBastionMetadata = cleanupContainersFlags.String("bastionMetadata", "", "A root directory where OpenShift clusters installs are located")
+	ptrDryRun = cleanupContainersFlags.String("dryRun", "false", "Only list the containers which would be deleted")
+	ptrShouldDebug = cleanupContainersFlags.String("shouldDebug", "false", "Should output debug output")
+
+	addProfileFlags(cleanupContainersFlags)
+
+	cleanupContainersFlags.Parse(args)
+
+	err = applyProfile(cleanupContainersFlags)
+	if err != nil {
+		return err
+	}
+
+	if ptrCloud == nil || *ptrCloud == "" {
+		return fmt.Errorf("Error: --cloud not specified")
+	}
+	if *ptrInfraID == "" && *ptrSuffix == "" {
+		return fmt.Errorf("Error: Either --infraID or --suffix should be specified")
+	}
+
+	olderThan, err = time.ParseDuration(*ptrOlderThan)
+	if err != nil {
+		return fmt.Errorf("Error: olderThan is not a duration (%s): %v\n", *ptrOlderThan, err)
+	}
+
+	switch strings.ToLower(*ptrDryRun) {
+	case "true":
+		dryRun = true
+	case "false":
+		dryRun = false
+	default:
+		return fmt.Errorf("Error: dryRun is not true/false (%s)\n", *ptrDryRun)
+	}
+
+	switch strings.ToLower(*ptrShouldDebug) {
+	case "true":
+		shouldDebug = true
+	case "false":
+		shouldDebug = false
+	default:
+		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
+	}
+
+	if shouldDebug {
+		out = os.Stderr
+	} else {
+		out = io.Discard
+	}
+	log = &logrus.Logger{
+		Out:       out,
+		Formatter: new(logrus.TextFormatter),
+		Level:     logrus.DebugLevel,
+	}
+
+	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
+
+	switch {
+	case *ptrBastionMetadata != "":
+		liveInfraIDs, err = gatherLiveInfraIDs(*ptrBastionMetadata)
+		if err != nil {
+			return err
+		}
+		log.Debugf("cleanupContainersCommand: liveInfraIDs = %+v", liveInfraIDs)
+	case dryRun:
+		fmt.Println("Warning: --bastionMetadata not set.  Containers of live clusters are listed as if they would be deleted.")
+	default:
+		return fmt.Errorf("Error: --bastionMetadata not specified.  It is needed to skip the containers of live clusters")
+	}
+
+	ctx, cancel = context.WithTimeout(context.TODO(), 15*time.Minute)
+	defer cancel()
+
+	allContainers, err = getAllContainers(ctx, *ptrCloud)
+	if err != nil {
+		return err
+	}
+
+	for _, container := range allContainers {
+		var (
+			created time.Time
+			age     time.Duration
+			action  string
+		)
+
+		if *ptrInfraID != "" && !strings.HasPrefix(container.Name, *ptrInfraID) {
+			log.Debugf("cleanupContainersCommand: SKIPPING %s (infraID)", container.Name)
+			continue
+		}
+		if *ptrSuffix != "" && !strings.HasSuffix(container.Name, *ptrSuffix) {
+			log.Debugf("cleanupContainersCommand: SKIPPING %s (suffix)", container.Name)
+			continue
+		}
+
+		created, err = getContainerCreated(ctx, *ptrCloud, container.Name)
+		if err != nil {
+			return err
+		}
+		age = time.Since(created).Truncate(time.Second)
+
+		switch {
+		case containerBelongsToLiveCluster(container.Name, liveInfraIDs):
+			action = "skipped (live metadata.json)"
+		case age < olderThan:
+			action = "skipped (too new)"
+		case dryRun:
+			action = "would delete"
+		default:
+			err = deleteContainer(ctx, *ptrCloud, container.Name)
+			if err != nil {
+				return err
+			}
+			action = "deleted"
+		}
+
+		fmt.Printf("%-50s %6d objects %12d bytes %12v old  %s\n", container.Name, container.Count, container.Bytes, age, action)
+	}
+
+	return nil
+}
+
+// gatherLiveInfraIDs returns the infraIDs of every metadata.json under rootPath.
+func gatherLiveInfraIDs(rootPath string) (sets.Set[string], error) {
+	var (
+		bastionInformations []bastionInformation
+		infraIDs            = sets.Set[string]{}
+		err                 error
+	)
+
+	bastionInformations, err = gatherBastionInformations(rootPath, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bastionInformation := range bastionInformations {
+		_, infraID, err := getMetadataClusterName(bastionInformation.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if infraID != "" {
+			infraIDs.Insert(infraID)
+		}
+	}
+
+	return infraIDs, nil
+}
+
+func containerBelongsToLiveCluster(containerName string, liveInfraIDs sets.Set[string]) bool {
+	for infraID := range liveInfraIDs {
+		if containerName == infraID || strings.HasPrefix(containerName, infraID+"-") {
+			return true
+		}
+	}
+
+	return false
+}
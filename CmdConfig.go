@@ -0,0 +1,78 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+func configCommand(configFlags *flag.FlagSet, args []string) error {
+	var (
+		configName  string
+		profileName string
+		profile     *Profile
+		values      map[string]string
+		keys        []string
+		err         error
+	)
+
+	if len(args) == 0 || args[0] != "show" {
+		return fmt.Errorf("Error: Expecting config show")
+	}
+
+	addProfileFlags(configFlags)
+
+	configFlags.Parse(args[1:])
+
+	configName = configFlags.Lookup("config").Value.String()
+
+	profileName, profile, err = loadProfile(configName, configFlags.Lookup("profile").Value.String())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("config:  %s\n", configName)
+	if profile == nil {
+		fmt.Printf("profile: (none)\n")
+		profile = &Profile{}
+	} else {
+		fmt.Printf("profile: %s\n", profileName)
+	}
+
+	values, err = profileValues(profile)
+	if err != nil {
+		return err
+	}
+
+	// The environment wins over the profile
+	if apiKey := os.Getenv(apiKeyEnv); apiKey != "" {
+		values["apiKey"] = apiKey
+	}
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	for _, key := range keys {
+		fmt.Printf("%-16s %s\n", key+":", maskSecret(key, values[key]))
+	}
+
+	return nil
+}
@@ -16,15 +16,12 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"math"
 	"strings"
 	"os"
-	"os/exec"
-	"path"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
@@ -38,8 +35,6 @@ import (
 
 	"github.com/sirupsen/logrus"
 
-	"k8s.io/apimachinery/pkg/util/wait"
-
 	"k8s.io/utils/ptr"
 )
 
@@ -53,21 +48,26 @@ var (
 
 func createBastionCommand(createBastionFlags *flag.FlagSet, args []string) error {
 	var (
-		out            io.Writer
-		ptrCloud       *string
-		ptrBastionName *string
-		ptrBastionRsa  *string
-		ptrFlavorName  *string
-		ptrImageName   *string
-		ptrNetworkName *string
-		ptrSshKeyName  *string
-		ptrDomainName  *string
-		ptrEnableHAP   *string
-		ptrServerIP    *string
-		ptrShouldDebug *string
-		ctx            context.Context
-		cancel         context.CancelFunc
-		err            error
+		out              io.Writer
+		ptrCloud         *string
+		ptrBastionName   *string
+		ptrBastionRsa    *string
+		ptrFlavorName    *string
+		ptrImageName     *string
+		ptrNetworkName   *string
+		ptrSshKeyName    *string
+		ptrDomainName    *string
+		ptrEnableHAP     *string
+		ptrServerIP      *string
+		ptrCertDirectory *string
+		ptrPreflight     *string
+		ptrPlannedVMs    *string
+		ptrPlannedFlavor *string
+		ptrShouldDebug   *string
+		requests         []preflightRequest
+		ctx              context.Context
+		cancel           context.CancelFunc
+		err              error
 	)
 
 	ptrCloud = createBastionFlags.String("cloud", "", "The cloud to use in clouds.yaml")
@@ -81,10 +81,20 @@ func createBastionCommand(createBastionFlags *flag.FlagSet, args []string) error
 	ptrDomainName = createBastionFlags.String("domainName", "", "The DNS domain to use")
 	ptrEnableHAP = createBastionFlags.String("enableHAProxy", "false", "Should install and enable HA Proxy demon")
 	ptrServerIP = createBastionFlags.String("serverIP", "", "The IP address of the server to send the command to")
+	ptrCertDirectory = addCertDirectoryFlag(createBastionFlags)
+	ptrPreflight = createBastionFlags.String("preflight", "true", "Should check the quota before creating the VM")
+	ptrPlannedVMs, ptrPlannedFlavor = addPlannedFlags(createBastionFlags)
 	ptrShouldDebug = createBastionFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(createBastionFlags)
+
 	createBastionFlags.Parse(args)
 
+	err = applyProfile(createBastionFlags)
+	if err != nil {
+		return err
+	}
+
 	if ptrCloud == nil || *ptrCloud == "" {
 		return fmt.Errorf("Error: --cloud not specified")
 	}
@@ -119,6 +129,18 @@ func createBastionCommand(createBastionFlags *flag.FlagSet, args []string) error
 		return fmt.Errorf("Error: enableHAProxy is not true/false (%s)\n", *ptrEnableHAP)
 	}
 
+	switch strings.ToLower(*ptrPreflight) {
+	case "true":
+		requests, err = preflightRequests(*ptrFlavorName, 1, *ptrPlannedVMs, *ptrPlannedFlavor)
+		if err != nil {
+			return err
+		}
+	case "false":
+		requests = nil
+	default:
+		return fmt.Errorf("Error: preflight is not true/false (%s)\n", *ptrPreflight)
+	}
+
 	switch strings.ToLower(*ptrShouldDebug) {
 	case "true":
 		shouldDebug = true
@@ -158,6 +180,11 @@ func createBastionCommand(createBastionFlags *flag.FlagSet, args []string) error
 		if strings.HasPrefix(err.Error(), "Could not find server named") {
 			fmt.Printf("Could not find server %s, creating...\n", *ptrBastionName)
 
+			err = runPreflight(ctx, *ptrCloud, requests, os.Stdout)
+			if err != nil {
+				return err
+			}
+
 			err = createServer(ctx,
 				*ptrCloud,
 				*ptrFlavorName,
@@ -186,7 +213,7 @@ func createBastionCommand(createBastionFlags *flag.FlagSet, args []string) error
 
 	if ptrServerIP != nil && *ptrServerIP != "" {
 		// Ask to set it up remotely
-		err = sendCreateBastion(*ptrServerIP, *ptrCloud, *ptrBastionName, *ptrDomainName)
+		err = sendCreateBastion(*ptrServerIP, *ptrCertDirectory, *ptrCloud, *ptrBastionName, *ptrDomainName)
 		if err != nil {
 			return err
 		}
@@ -322,63 +349,35 @@ func createServer(ctx context.Context, cloudName string, flavorName string, imag
 
 func addServerKnownHosts(ctx context.Context, ipAddress string) error {
 	var (
-		homeDir      string
-		outb         []byte
-		outs         string
-		exitError    *exec.ExitError
-		err          error
+		found bool
+		outb  []byte
+		err   error
 	)
 
-	homeDir, err = os.UserHomeDir()
-	if err != nil {
+	// Does ipAddress already exist in the known hosts file?
+	found, err = findServerKnownHosts(ipAddress)
+	log.Debugf("addServerKnownHosts: found = %v, err = %v", found, err)
+	if err != nil || found {
 		return err
 	}
-	log.Debugf("setupBastionServer: homeDir = %s", homeDir)
-
-	// Does ipAddress already exist in the known hosts file?
-	outb, err = runSplitCommand2([]string{
-		"ssh-keygen",
-		"-H",
-		"-F",
-		ipAddress,
-	})
-	outs = strings.TrimSpace(string(outb))
-	log.Debugf("setupBastionServer: outs = \"%s\"", outs)
-	if errors.As(err, &exitError) {
-		log.Debugf("setupBastionServer: exitError.ExitCode() = %+v\n", exitError.ExitCode())
-
-		log.Debugf("setupBastionServer: %v", exitError.ExitCode() == 1)
-		if exitError.ExitCode() == 1 {
-
-			outb, err = keyscanServer(ctx, ipAddress, false)
-			if err != nil {
-				return err
-			}
 
-			knownHosts := path.Join(homeDir, ".ssh/known_hosts")
-			log.Debugf("setupBastionServer: knownHosts = %s", knownHosts)
-
-			fileKnownHosts, err := os.OpenFile(knownHosts, os.O_APPEND|os.O_RDWR, 0644)
-			if err != nil {
-				return err
-			}
-
-			fileKnownHosts.Write(outb)
-
-			defer fileKnownHosts.Close()
-		}
+	outb, err = keyscanServer(ctx, ipAddress, false)
+	if err != nil {
+		return err
 	}
 
-	return err
+	return appendServerKnownHosts(outb)
 }
 
 func setupBastionServer(ctx context.Context, cloudName string, serverName string, domainName string, bastionRsa string) error {
 	var (
 		server       servers.Server
 		ipAddress    string
+		target       sshTarget
 		outb         []byte
 		outs         string
-		exitError    *exec.ExitError
+		exitStatus   int
+		ok           bool
 		apiKey       string
 		err          error
 	)
@@ -408,66 +407,56 @@ func setupBastionServer(ctx context.Context, cloudName string, serverName string
 			return err
 		}
 
+		target = sshTarget{
+			Host:        ipAddress,
+			Username:    "cloud-user",
+			KeyFilename: bastionRsa,
+		}
+		defer sshCloseAll()
+
 		for i := 0; i < 10; i++ {
-			outb, err = runSplitCommand2([]string{
-				"ssh",
-				"-i",
-				bastionRsa,
-				fmt.Sprintf("cloud-user@%s", ipAddress),
+			outb, err = sshRun(ctx, target, []string{
 				"echo",
 				"ready",
 			})
 			outs = strings.TrimSpace(string(outb))
-			log.Debugf("setupBastionServer: outs = \"%s\"", outs)
+			log.Debugf("setupBastionServer: outs = \"%s\", err = %v", outs, err)
 			if outs == "ready" {
 				break
 			}
 			time.Sleep(15 * time.Second)
 		}
+		if outs != "ready" && err != nil {
+			return fmt.Errorf("Error: HAProxy not ready in time: %w", err)
+		}
 		if outs != "ready" {
-			return fmt.Errorf("Error: HAProxy not ready in time")
+			return fmt.Errorf("Error: HAProxy not ready in time, last output %q", outs)
 		}
 
-		outb, err = runSplitCommand2([]string{
-			"ssh",
-			"-i",
-			bastionRsa,
-			fmt.Sprintf("cloud-user@%s", ipAddress),
+		// rpm -q exits with 1 when the package is not installed
+		_, err = sshRun(ctx, target, []string{
 			"rpm",
 			"-q",
 			"haproxy",
 		})
-		outs = strings.TrimSpace(string(outb))
-		log.Debugf("setupBastionServer: outs = \"%s\"", outs)
-		if errors.As(err, &exitError) {
-			log.Debugf("setupBastionServer: exitError.ExitCode() = %+v\n", exitError.ExitCode())
-
-			if exitError.ExitCode() == 1 && outs == "package haproxy is not installed" {
-				outb, err = runSplitCommand2([]string{
-					"ssh",
-					"-i",
-					bastionRsa,
-					fmt.Sprintf("cloud-user@%s", ipAddress),
-					"sudo",
-					"dnf",
-					"install",
-					"-y",
-					"haproxy",
-				})
-				outs = strings.TrimSpace(string(outb))
-				log.Debugf("setupBastionServer: outs = %s", outs)
-				log.Debugf("setupBastionServer: err = %+v", err)
-			}
-		} else if err != nil {
+		exitStatus, ok = sshExitStatus(err)
+		log.Debugf("setupBastionServer: exitStatus = %d, ok = %v", exitStatus, ok)
+		if ok && exitStatus == 1 {
+			outb, err = sshRun(ctx, target, []string{
+				"sudo",
+				"dnf",
+				"install",
+				"-y",
+				"haproxy",
+			})
+			log.Debugf("setupBastionServer: outs = %s", strings.TrimSpace(string(outb)))
+		}
+		if err != nil {
 			log.Debugf("setupBastionServer: err = %+v", err)
 			return err
 		}
 
-		outb, err = runSplitCommand2([]string{
-			"ssh",
-			"-i",
-			bastionRsa,
-			fmt.Sprintf("cloud-user@%s", ipAddress),
+		outb, err = sshRun(ctx, target, []string{
 			"sudo",
 			"stat",
 			"-c",
@@ -481,29 +470,19 @@ func setupBastionServer(ctx context.Context, cloudName string, serverName string
 			return err
 		}
 		if outs != "646" {
-			outb, err = runSplitCommand2([]string{
-				"ssh",
-				"-i",
-				bastionRsa,
-				fmt.Sprintf("cloud-user@%s", ipAddress),
+			_, err = sshRun(ctx, target, []string{
 				"sudo",
 				"chmod",
 				"646",
 				"/etc/haproxy/haproxy.cfg",
 			})
-			outs = strings.TrimSpace(string(outb))
-			log.Debugf("setupBastionServer: outb = \"%s\"", outs)
 			if err != nil {
 				log.Debugf("setupBastionServer: err = %+v", err)
 				return err
 			}
 		}
 
-		outb, err = runSplitCommand2([]string{
-			"ssh",
-			"-i",
-			bastionRsa,
-			fmt.Sprintf("cloud-user@%s", ipAddress),
+		outb, err = sshRun(ctx, target, []string{
 			"sudo",
 			"getsebool",
 			"haproxy_connect_any",
@@ -515,53 +494,35 @@ func setupBastionServer(ctx context.Context, cloudName string, serverName string
 			return err
 		}
 		if outs != "haproxy_connect_any --> on" {
-			outb, err = runSplitCommand2([]string{
-				"ssh",
-				"-i",
-				bastionRsa,
-				fmt.Sprintf("cloud-user@%s", ipAddress),
+			_, err = sshRun(ctx, target, []string{
 				"sudo",
 				"setsebool",
 				"-P",
 				"haproxy_connect_any=1",
 			})
-			outs = strings.TrimSpace(string(outb))
-			log.Debugf("setupBastionServer: outb = \"%s\"", outs)
 			if err != nil {
 				log.Debugf("setupBastionServer: err = %+v", err)
 				return err
 			}
 		}
 
-		outb, err = runSplitCommand2([]string{
-			"ssh",
-			"-i",
-			bastionRsa,
-			fmt.Sprintf("cloud-user@%s", ipAddress),
+		_, err = sshRun(ctx, target, []string{
 			"sudo",
 			"systemctl",
 			"enable",
 			"haproxy.service",
 		})
-		outs = strings.TrimSpace(string(outb))
-		log.Debugf("setupBastionServer: outb = \"%s\"", outs)
 		if err != nil {
 			log.Debugf("setupBastionServer: err = %+v", err)
 			return err
 		}
 
-		outb, err = runSplitCommand2([]string{
-			"ssh",
-			"-i",
-			bastionRsa,
-			fmt.Sprintf("cloud-user@%s", ipAddress),
+		_, err = sshRun(ctx, target, []string{
 			"sudo",
 			"systemctl",
 			"start",
 			"haproxy.service",
 		})
-		outs = strings.TrimSpace(string(outb))
-		log.Debugf("setupBastionServer: outb = \"%s\"", outs)
 		if err != nil {
 			log.Debugf("setupBastionServer: err = %+v", err)
 			return err
@@ -618,69 +579,6 @@ func writeBastionIP(ctx context.Context, cloudName string, serverName string) er
 	return nil
 }
 
-func removeCommentLines(input string) string {
-	var (
-		inputLines  []string
-		resultLines []string
-	)
-
-	log.Debugf("removeCommentLines: input = \"%s\"", input)
-
-	inputLines = strings.Split(input, "\n")
-
-	for _, line := range inputLines {
-		if !strings.HasPrefix(line, "#") {
-			resultLines = append(resultLines, line)
-		}
-	}
-
-	log.Debugf("removeCommentLines: resultLines = \"%s\"", resultLines)
-
-	return strings.Join(resultLines, "\n")
-}
-
-func keyscanServer(ctx context.Context, ipAddress string, silent bool) ([]byte, error) {
-	var (
-		outb []byte
-		outs string
-		err  error
-	)
-
-	backoff := wait.Backoff{
-		Duration: 1 * time.Second,
-		Factor:   1.1,
-		Cap:      leftInContext(ctx),
-		Steps:    math.MaxInt32,
-	}
-
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
-		var (
-			err2 error
-		)
-
-		outb, err2 = runSplitCommandNoErr([]string{
-			"ssh-keyscan",
-			ipAddress,
-		},
-			silent)
-		outs = strings.TrimSpace(string(outb))
-		log.Debugf("keyscanServer: outs = %s", outs)
-		if err2 != nil {
-			return false, nil
-		}
-
-		return true, nil
-	})
-
-	if err == nil {
-		// Get rid of the comment lines generated by ssh-keyscan
-		outLines := removeCommentLines(outs)
-		outb = []byte(outLines)
-	}
-
-	return outb, err
-}
-
 func dnsForServer(ctx context.Context, cloudName string, apiKey string, bastionName string, domainName string) error {
 	var (
 		server       servers.Server
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -28,25 +29,45 @@ func createClusterCommand(createClusterFlags *flag.FlagSet, args []string) error
 	var (
 		out            io.Writer
 		ptrDirectory   *string
+		ptrFromPhase   *string
+		ptrToPhase     *string
+		ptrResume      *string
+		ptrStreamJSON  *string
+		ptrImageFile   *string
+		ptrImageName   *string
+		ptrRules       *string
+		ptrDryRun      *string
 		ptrShouldDebug *string
-		functions      = []func(string) error{
-			createClusterPhase1,
-			createClusterPhase2,
-			createClusterPhase3,
-			createClusterPhase4,
-			createClusterPhase5,
-			createClusterPhase6,
-			createClusterPhase7,
-//			createClusterPhase8,
-		}
+		fromPhase      int
+		toPhase        int
+		resume         bool
+		dryRun         bool
+		fromPhaseSet   = false
+		journal        *createClusterJournal
 		err            error
 	)
 
 	ptrDirectory = createClusterFlags.String("directory", "", "The location of the installation directory")
+	ptrFromPhase = createClusterFlags.String("fromPhase", "1", "The first phase to run")
+	ptrToPhase = createClusterFlags.String("toPhase", strconv.Itoa(len(createClusterPhases)), "The last phase to run")
+	ptrResume = createClusterFlags.String("resume", "false", "Should continue after the last completed phase")
+	// NOTE: These are optional
+	ptrStreamJSON = createClusterFlags.String("streamJSON", "", "The CoreOS stream metadata file (default from openshift-install)")
+	ptrImageFile = createClusterFlags.String("rhcosImageFile", "", "The RHCOS qcow2 to upload when the image is not in Glance")
+	ptrImageName = createClusterFlags.String("rhcosImageName", "", "The name of the RHCOS image (default from the stream metadata)")
+	ptrRules = createClusterFlags.String("manifestRules", "", "The rules file to change the manifests with (default the PowerVC rules)")
+	ptrDryRun = createClusterFlags.String("dryRun", "false", "Only print a diff of the files which would be rewritten")
 	ptrShouldDebug = createClusterFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(createClusterFlags)
+
 	createClusterFlags.Parse(args)
 
+	err = applyProfile(createClusterFlags)
+	if err != nil {
+		return err
+	}
+
 	switch strings.ToLower(*ptrShouldDebug) {
 	case "true":
 		shouldDebug = true
@@ -56,6 +77,43 @@ func createClusterCommand(createClusterFlags *flag.FlagSet, args []string) error
 		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
 	}
 
+	switch strings.ToLower(*ptrResume) {
+	case "true":
+		resume = true
+	case "false":
+		resume = false
+	default:
+		return fmt.Errorf("Error: resume is not true/false (%s)\n", *ptrResume)
+	}
+
+	switch strings.ToLower(*ptrDryRun) {
+	case "true":
+		dryRun = true
+	case "false":
+		dryRun = false
+	default:
+		return fmt.Errorf("Error: dryRun is not true/false (%s)\n", *ptrDryRun)
+	}
+
+	fromPhase, err = strconv.Atoi(*ptrFromPhase)
+	if err != nil || fromPhase < 1 || fromPhase > len(createClusterPhases) {
+		return fmt.Errorf("Error: fromPhase is not between 1 and %d (%s)\n", len(createClusterPhases), *ptrFromPhase)
+	}
+
+	toPhase, err = strconv.Atoi(*ptrToPhase)
+	if err != nil || toPhase < 1 || toPhase > len(createClusterPhases) {
+		return fmt.Errorf("Error: toPhase is not between 1 and %d (%s)\n", len(createClusterPhases), *ptrToPhase)
+	}
+
+	createClusterFlags.Visit(func(f *flag.Flag) {
+		if f.Name == "fromPhase" {
+			fromPhaseSet = true
+		}
+	})
+	if resume && fromPhaseSet {
+		return fmt.Errorf("Error: Both --resume and --fromPhase cannot be specified")
+	}
+
 	if shouldDebug {
 		out = os.Stderr
 	} else {
@@ -73,8 +131,47 @@ func createClusterCommand(createClusterFlags *flag.FlagSet, args []string) error
 
 	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
 
-	for _, function := range functions {
-		err = function(*ptrDirectory)
+	rhcosStreamFilename = *ptrStreamJSON
+	rhcosImageFilename = *ptrImageFile
+	rhcosImageName = *ptrImageName
+	manifestRulesFilename = *ptrRules
+
+	journal, err = loadCreateClusterJournal(*ptrDirectory)
+	if err != nil {
+		return err
+	}
+
+	if resume {
+		fromPhase = journal.lastCompleted() + 1
+		if fromPhase > toPhase {
+			fmt.Printf("Phases 1 through %d are already completed\n", toPhase)
+			return nil
+		}
+		fmt.Printf("Resuming at phase %d\n", fromPhase)
+	}
+
+	if fromPhase > toPhase {
+		return fmt.Errorf("Error: fromPhase (%d) is after toPhase (%d)", fromPhase, toPhase)
+	}
+
+	// Check the whole range first, rather than failing half way through it.
+	for _, phase := range createClusterPhases[fromPhase-1 : toPhase] {
+		err = journal.checkRunnable(phase)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, phase := range createClusterPhases[fromPhase-1 : toPhase] {
+		if dryRun {
+			err = dryRunCreateClusterPhase(*ptrDirectory, phase)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = runCreateClusterPhase(*ptrDirectory, journal, phase)
 		if err != nil {
 			return err
 		}
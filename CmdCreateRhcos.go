@@ -18,14 +18,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"strings"
 	"os"
-	"os/exec"
-	"path"
 	"time"
 
 	igntypes "github.com/coreos/ignition/v2/config/v3_2/types"
@@ -39,27 +36,28 @@ import (
 
 func createRhcosCommand(createRhcosFlags *flag.FlagSet, args []string) error {
 	var (
-		out             io.Writer
-		apiKey          string
-		ptrCloud        *string
-		ptrRhcosName    *string
-		ptrFlavorName   *string
-		ptrImageName    *string
-		ptrNetworkName  *string
-		ptrPasswdHash   *string
-		ptrSshPublicKey *string
-		ptrDomainName   *string
-		ptrShouldDebug  *string
-		ctx             context.Context
-		cancel          context.CancelFunc
-		userData        []byte
-		foundServer     servers.Server
-		err             error
+		out              io.Writer
+		apiKey           string
+		ptrCloud         *string
+		ptrRhcosName     *string
+		ptrFlavorName    *string
+		ptrImageName     *string
+		ptrNetworkName   *string
+		ptrPasswdHash    *string
+		ptrSshPublicKey  *string
+		ptrDomainName    *string
+		ptrPreflight     *string
+		ptrPlannedVMs    *string
+		ptrPlannedFlavor *string
+		ptrShouldDebug   *string
+		requests         []preflightRequest
+		ctx              context.Context
+		cancel           context.CancelFunc
+		userData         []byte
+		foundServer      servers.Server
+		err              error
 	)
 
-	// NOTE: This is optional
-	apiKey = os.Getenv("IBMCLOUD_API_KEY")
-
 	ptrCloud = createRhcosFlags.String("cloud", "", "The cloud to use in clouds.yaml")
 	ptrRhcosName = createRhcosFlags.String("rhcosName", "", "The name of the bastion VM to use")
 	ptrFlavorName = createRhcosFlags.String("flavorName", "", "The name of the flavor to use")
@@ -69,10 +67,22 @@ func createRhcosCommand(createRhcosFlags *flag.FlagSet, args []string) error {
 	ptrSshPublicKey = createRhcosFlags.String("sshPublicKey", "", "The contents of the ssh public key to use")
 	// NOTE: This is optional
 	ptrDomainName = createRhcosFlags.String("domainName", "", "The DNS domain to use")
+	ptrPreflight = createRhcosFlags.String("preflight", "true", "Should check the quota before creating the VM")
+	ptrPlannedVMs, ptrPlannedFlavor = addPlannedFlags(createRhcosFlags)
 	ptrShouldDebug = createRhcosFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(createRhcosFlags)
+
 	createRhcosFlags.Parse(args)
 
+	err = applyProfile(createRhcosFlags)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: This is optional
+	apiKey = os.Getenv("IBMCLOUD_API_KEY")
+
 	if ptrCloud == nil || *ptrCloud == "" {
 		return fmt.Errorf("Error: --cloud not specified")
 	}
@@ -95,6 +105,18 @@ func createRhcosCommand(createRhcosFlags *flag.FlagSet, args []string) error {
 		return fmt.Errorf("Error: --passwdHash not specified")
 	}
 
+	switch strings.ToLower(*ptrPreflight) {
+	case "true":
+		requests, err = preflightRequests(*ptrFlavorName, 1, *ptrPlannedVMs, *ptrPlannedFlavor)
+		if err != nil {
+			return err
+		}
+	case "false":
+		requests = nil
+	default:
+		return fmt.Errorf("Error: preflight is not true/false (%s)\n", *ptrPreflight)
+	}
+
 	switch strings.ToLower(*ptrShouldDebug) {
 	case "true":
 		shouldDebug = true
@@ -131,6 +153,11 @@ func createRhcosCommand(createRhcosFlags *flag.FlagSet, args []string) error {
 		if strings.HasPrefix(err.Error(), "Could not find server named") {
 			fmt.Printf("Could not find server %s, creating...\n", *ptrRhcosName)
 
+			err = runPreflight(ctx, *ptrCloud, requests, os.Stdout)
+			if err != nil {
+				return err
+			}
+
 			err = createServer(ctx,
 				*ptrCloud,
 				*ptrFlavorName,
@@ -175,10 +202,6 @@ func createRhcosCommand(createRhcosFlags *flag.FlagSet, args []string) error {
 func setupRhcosServer(ctx context.Context, cloudName string, server servers.Server) error {
 	var (
 		ipAddress    string
-		homeDir      string
-		outb         []byte
-		outs         string
-		exitError    *exec.ExitError
 		err          error
 	)
 
@@ -194,44 +217,10 @@ func setupRhcosServer(ctx context.Context, cloudName string, server servers.Serv
 
 	log.Debugf("setupRhcosServer: ipAddress = %s", ipAddress)
 
-	homeDir, err = os.UserHomeDir()
+	err = addServerKnownHosts(ctx, ipAddress)
 	if err != nil {
 		return err
 	}
-	log.Debugf("setupRhcosServer: homeDir = %s", homeDir)
-
-	outb, err = runSplitCommand2([]string{
-		"ssh-keygen",
-		"-H",
-		"-F",
-		ipAddress,
-	})
-	outs = strings.TrimSpace(string(outb))
-	log.Debugf("setupRhcosServer: outs = \"%s\"", outs)
-	if errors.As(err, &exitError) {
-		log.Debugf("setupRhcosServer: exitError.ExitCode() = %+v\n", exitError.ExitCode())
-
-		log.Debugf("setupRhcosServer: %v", exitError.ExitCode() == 1)
-		if exitError.ExitCode() == 1 {
-
-			outb, err = keyscanServer(ctx, ipAddress, false)
-			if err != nil {
-				return err
-			}
-
-			knownHosts := path.Join(homeDir, ".ssh/known_hosts")
-			log.Debugf("setupRhcosServer: knownHosts = %s", knownHosts)
-
-			fileKnownHosts, err := os.OpenFile(knownHosts, os.O_APPEND|os.O_RDWR, 0644)
-			if err != nil {
-				return err
-			}
-
-			fileKnownHosts.Write(outb)
-
-			defer fileKnownHosts.Close()
-		}
-	}
 
 	fmt.Printf("Setting up server %s...\n", server.Name)
 	return nil
@@ -0,0 +1,326 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+
+	"github.com/IBM/networking-go-sdk/dnsrecordsv1"
+
+	"github.com/sirupsen/logrus"
+)
+
+// deletedResource records what happened to one resource during a delete-cluster run.
+type deletedResource struct {
+	Kind   string
+	Name   string
+	Action string
+}
+
+func deleteClusterCommand(deleteClusterFlags *flag.FlagSet, args []string) error {
+	var (
+		out            io.Writer
+		apiKey         string
+		ptrMetadata    *string
+		ptrCloud       *string
+		ptrBastionName *string
+		ptrDomainName  *string
+		ptrDryRun      *string
+		ptrShouldDebug *string
+		dryRun         = false
+		metadata       *Metadata
+		cloud          string
+		bastionName    string
+		ctx            context.Context
+		cancel         context.CancelFunc
+		results        []deletedResource
+		err            error
+	)
+
+	ptrMetadata = deleteClusterFlags.String("metadata", "", "The location of the metadata.json file")
+	// NOTE: These are optional
+	ptrCloud = deleteClusterFlags.String("cloud", "", "The cloud to use in clouds.yaml (defaults to the cloud in metadata.json)")
+	ptrBastionName = deleteClusterFlags.String("bastionName", "", "The name of the bastion VM (defaults to the cluster name)")
+	ptrDomainName = deleteClusterFlags.String("domainName", "", "The DNS domain to use")
+	ptrDryRun = deleteClusterFlags.String("dryRun", "false", "Only report what would be deleted")
+	ptrShouldDebug = deleteClusterFlags.String("shouldDebug", "false", "Should output debug output")
+
+	addProfileFlags(deleteClusterFlags)
+
+	deleteClusterFlags.Parse(args)
+
+	err = applyProfile(deleteClusterFlags)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: This is optional
+	apiKey = os.Getenv("IBMCLOUD_API_KEY")
+
+	if ptrMetadata == nil || *ptrMetadata == "" {
+		return fmt.Errorf("Error: --metadata not specified")
+	}
+
+	switch strings.ToLower(*ptrDryRun) {
+	case "true":
+		dryRun = true
+	case "false":
+		dryRun = false
+	default:
+		return fmt.Errorf("Error: dryRun is not true/false (%s)\n", *ptrDryRun)
+	}
+
+	switch strings.ToLower(*ptrShouldDebug) {
+	case "true":
+		shouldDebug = true
+	case "false":
+		shouldDebug = false
+	default:
+		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
+	}
+
+	if shouldDebug {
+		out = os.Stderr
+	} else {
+		out = io.Discard
+	}
+	log = &logrus.Logger{
+		Out:       out,
+		Formatter: new(logrus.TextFormatter),
+		Level:     logrus.DebugLevel,
+	}
+
+	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
+
+	metadata, err = NewMetadataFromCCMetadata(*ptrMetadata)
+	if err != nil {
+		return fmt.Errorf("Error: Could not read metadata from %s\n", *ptrMetadata)
+	}
+
+	cloud = *ptrCloud
+	if cloud == "" {
+		cloud = metadata.GetCloud()
+	}
+	if cloud == "" {
+		return fmt.Errorf("Error: --cloud not specified and metadata.json does not contain one")
+	}
+
+	bastionName = *ptrBastionName
+	if bastionName == "" {
+		bastionName = metadata.GetClusterName()
+	}
+	log.Debugf("deleteClusterCommand: cloud = %s, bastionName = %s, infraID = %s", cloud, bastionName, metadata.GetInfraID())
+
+	ctx, cancel = context.WithTimeout(context.TODO(), 30*time.Minute)
+	defer cancel()
+
+	results, err = deleteClusterResources(ctx, cloud, apiKey, metadata, bastionName, *ptrDomainName, dryRun)
+
+	fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	for _, result := range results {
+		fmt.Printf("%-12s %-50s %s\n", result.Kind, result.Name, result.Action)
+	}
+
+	return err
+}
+
+// deleteClusterResources removes everything this tool created for a cluster.  Every step is
+// idempotent, so it is safe to run again after a partial failure.
+func deleteClusterResources(ctx context.Context, cloud string, apiKey string, metadata *Metadata, bastionName string, domainName string, dryRun bool) ([]deletedResource, error) {
+	var (
+		results       []deletedResource
+		server        servers.Server
+		foundServer   = true
+		ipAddress     string
+		dnsService    *dnsrecordsv1.DnsRecordsV1
+		portName      string
+		foundPorts    []ports.Port
+		knownHosts    []string
+		containerName string
+		found         bool
+		action        string
+		err           error
+	)
+
+	action = "deleted"
+	if dryRun {
+		action = "would delete"
+	}
+
+	server, err = findServer(ctx, cloud, bastionName)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), "Could not find server named") {
+			return results, err
+		}
+		foundServer = false
+	}
+
+	if foundServer {
+		_, ipAddress, err = findIpAddress(server)
+		if err != nil {
+			return results, err
+		}
+	}
+	log.Debugf("deleteClusterResources: foundServer = %v, ipAddress = %s", foundServer, ipAddress)
+
+	// The port from createServer, looked up before anything is deleted since its IP address is
+	// the last known one of the bastion.
+	portName = fmt.Sprintf("%s-port", bastionName)
+
+	foundPorts, err = findPorts(ctx, cloud, portName)
+	if err != nil {
+		return results, err
+	}
+
+	knownHosts = []string{ipAddress}
+	for _, port := range foundPorts {
+		for _, fixedIP := range port.FixedIPs {
+			knownHosts = append(knownHosts, fixedIP.IPAddress)
+		}
+	}
+	if domainName != "" {
+		knownHosts = append(knownHosts, fmt.Sprintf("%s.%s", bastionName, domainName))
+	}
+
+	// The DNS records from dnsForServer
+	if apiKey != "" && domainName != "" {
+		dnsService, err = loadDnsServiceForDomain(ctx, apiKey, domainName)
+		if err != nil {
+			return results, err
+		}
+
+		for _, record := range []struct{ prefix, recordType string }{
+			{"api", dnsrecordsv1.CreateDnsRecordOptions_Type_A},
+			{"api-int", dnsrecordsv1.CreateDnsRecordOptions_Type_A},
+			{"*.apps", dnsrecordsv1.CreateDnsRecordOptions_Type_Cname},
+		} {
+			var (
+				hostname string
+				recordID string
+				content  string
+			)
+
+			hostname = fmt.Sprintf("%s.%s.%s", record.prefix, bastionName, domainName)
+
+			recordID, content, err = findDNSRecord(ctx, dnsService, hostname)
+			if err != nil {
+				return results, err
+			}
+			if record.recordType == dnsrecordsv1.CreateDnsRecordOptions_Type_A {
+				knownHosts = append(knownHosts, content)
+			}
+			if recordID == "" {
+				results = append(results, deletedResource{"dns", hostname, "not found"})
+				continue
+			}
+
+			if !dryRun {
+				err = createOrDeletePublicDNSRecord(ctx, record.recordType, hostname, "", false, dnsService)
+				if err != nil {
+					return results, err
+				}
+			}
+			results = append(results, deletedResource{"dns", hostname, action})
+		}
+	} else {
+		fmt.Println("Warning: IBMCLOUD_API_KEY or --domainName not set.  Skipping the DNS records.")
+	}
+
+	// The known_hosts entries for the bastion, even when the server is already gone, so that
+	// they do not break the next bastion which reuses the IP address.
+	slices.Sort(knownHosts)
+	for _, host := range slices.Compact(knownHosts) {
+		if host == "" {
+			continue
+		}
+
+		found, err = findServerKnownHosts(host)
+		if err != nil {
+			return results, err
+		}
+		if !found {
+			results = append(results, deletedResource{"known_hosts", host, "not found"})
+			continue
+		}
+
+		if !dryRun {
+			err = removeServerKnownHosts(host)
+			if err != nil {
+				return results, err
+			}
+		}
+		results = append(results, deletedResource{"known_hosts", host, action})
+	}
+
+	// The bastion VM from createServer
+	if foundServer {
+		if !dryRun {
+			fmt.Printf("Deleting server %s...\n", server.Name)
+
+			err = deleteServer(ctx, cloud, server)
+			if err != nil {
+				return results, err
+			}
+		}
+		results = append(results, deletedResource{"server", bastionName, action})
+	} else {
+		results = append(results, deletedResource{"server", bastionName, "not found"})
+	}
+
+	// The port from createServer
+	if len(foundPorts) == 0 {
+		results = append(results, deletedResource{"port", portName, "not found"})
+	}
+	for _, port := range foundPorts {
+		if !dryRun {
+			err = deletePort(ctx, cloud, port.ID)
+			if err != nil {
+				return results, err
+			}
+		}
+		results = append(results, deletedResource{"port", fmt.Sprintf("%s (%s)", port.Name, port.ID), action})
+	}
+
+	// The Swift container from createClusterPhase4
+	containerName = fmt.Sprintf("%s-ignition", metadata.GetInfraID())
+
+	found, err = findContainer(ctx, cloud, containerName)
+	if err != nil {
+		return results, err
+	}
+	if found {
+		if !dryRun {
+			err = deleteContainer(ctx, cloud, containerName)
+			if err != nil {
+				return results, err
+			}
+		}
+		results = append(results, deletedResource{"container", containerName, action})
+	} else {
+		results = append(results, deletedResource{"container", containerName, "not found"})
+	}
+
+	return results, nil
+}
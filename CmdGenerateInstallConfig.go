@@ -0,0 +1,282 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/availabilityzones"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/subnets"
+
+	"github.com/sirupsen/logrus"
+)
+
+func generateInstallConfigCommand(generateInstallConfigFlags *flag.FlagSet, args []string) error {
+	var (
+		out                 io.Writer
+		ptrCloud            *string
+		ptrDirectory        *string
+		ptrClusterName      *string
+		ptrBaseDomain       *string
+		ptrBastionName      *string
+		ptrFlavorName       *string
+		ptrImageName        *string
+		ptrNetworkName      *string
+		ptrAvailabilityZone *string
+		ptrWorkers          *string
+		ptrPullSecretFile   *string
+		ptrSshPublicKeyFile *string
+		ptrShouldDebug      *string
+		inputs              installConfigInputs
+		filename            string
+		content             []byte
+		ctx                 context.Context
+		cancel              context.CancelFunc
+		err                 error
+	)
+
+	ptrCloud = generateInstallConfigFlags.String("cloud", "", "The cloud to use in clouds.yaml")
+	ptrDirectory = generateInstallConfigFlags.String("directory", "", "The location of the installation directory")
+	ptrClusterName = generateInstallConfigFlags.String("clusterName", "", "The name of the cluster")
+	ptrBaseDomain = generateInstallConfigFlags.String("baseDomain", "", "The base domain of the cluster")
+	// NOTE: This is optional
+	ptrBastionName = generateInstallConfigFlags.String("bastionName", "", "The name of the bastion VM (default --clusterName)")
+	ptrFlavorName = generateInstallConfigFlags.String("flavorName", "", "The name of the flavor for the nodes")
+	ptrImageName = generateInstallConfigFlags.String("imageName", "", "The name of the RHCOS image for the nodes")
+	ptrNetworkName = generateInstallConfigFlags.String("networkName", "", "The name of the network for the nodes")
+	ptrAvailabilityZone = generateInstallConfigFlags.String("availabilityZone", "", "The availability zone for the nodes")
+	ptrWorkers = generateInstallConfigFlags.String("workers", "3", "The number of workers")
+	ptrPullSecretFile = generateInstallConfigFlags.String("pullSecretFile", "", "The file with the pull secret")
+	ptrSshPublicKeyFile = generateInstallConfigFlags.String("sshPublicKeyFile", "", "The file with the ssh public key for the nodes")
+	ptrShouldDebug = generateInstallConfigFlags.String("shouldDebug", "false", "Should output debug output")
+
+	addProfileFlags(generateInstallConfigFlags)
+
+	generateInstallConfigFlags.Parse(args)
+
+	err = applyProfile(generateInstallConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	if ptrCloud == nil || *ptrCloud == "" {
+		return fmt.Errorf("Error: --cloud not specified")
+	}
+	if ptrDirectory == nil || *ptrDirectory == "" {
+		return fmt.Errorf("Error: --directory not specified")
+	}
+	if ptrClusterName == nil || *ptrClusterName == "" {
+		return fmt.Errorf("Error: --clusterName not specified")
+	}
+	if ptrBaseDomain == nil || *ptrBaseDomain == "" {
+		return fmt.Errorf("Error: --baseDomain not specified")
+	}
+	if ptrFlavorName == nil || *ptrFlavorName == "" {
+		return fmt.Errorf("Error: --flavorName not specified")
+	}
+	if ptrImageName == nil || *ptrImageName == "" {
+		return fmt.Errorf("Error: --imageName not specified")
+	}
+	if ptrNetworkName == nil || *ptrNetworkName == "" {
+		return fmt.Errorf("Error: --networkName not specified")
+	}
+	if ptrAvailabilityZone == nil || *ptrAvailabilityZone == "" {
+		return fmt.Errorf("Error: --availabilityZone not specified")
+	}
+	if ptrPullSecretFile == nil || *ptrPullSecretFile == "" {
+		return fmt.Errorf("Error: --pullSecretFile not specified")
+	}
+	if ptrSshPublicKeyFile == nil || *ptrSshPublicKeyFile == "" {
+		return fmt.Errorf("Error: --sshPublicKeyFile not specified")
+	}
+	if *ptrBastionName == "" {
+		*ptrBastionName = *ptrClusterName
+	}
+
+	switch strings.ToLower(*ptrShouldDebug) {
+	case "true":
+		shouldDebug = true
+	case "false":
+		shouldDebug = false
+	default:
+		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
+	}
+
+	if shouldDebug {
+		out = os.Stderr
+	} else {
+		out = io.Discard
+	}
+	log = &logrus.Logger{
+		Out:       out,
+		Formatter: new(logrus.TextFormatter),
+		Level:     logrus.DebugLevel,
+	}
+
+	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
+
+	inputs = installConfigInputs{
+		Cloud:            *ptrCloud,
+		ClusterName:      *ptrClusterName,
+		BaseDomain:       *ptrBaseDomain,
+		AvailabilityZone: *ptrAvailabilityZone,
+		FlavorName:       *ptrFlavorName,
+		ImageName:        *ptrImageName,
+	}
+
+	inputs.Workers, err = strconv.Atoi(*ptrWorkers)
+	if err != nil || inputs.Workers < 0 {
+		return fmt.Errorf("Error: workers is not a number of zero or more (%s)\n", *ptrWorkers)
+	}
+
+	content, err = os.ReadFile(*ptrPullSecretFile)
+	if err != nil {
+		return fmt.Errorf("Error: Could not read the pull secret: %v", err)
+	}
+	inputs.PullSecret = strings.TrimSpace(string(content))
+
+	content, err = os.ReadFile(*ptrSshPublicKeyFile)
+	if err != nil {
+		return fmt.Errorf("Error: Could not read the ssh public key: %v", err)
+	}
+	inputs.SSHKey = strings.TrimSpace(string(content))
+
+	filename = filepath.Join(*ptrDirectory, "install-config.yaml")
+	_, err = os.Stat(filename)
+	if err == nil {
+		return fmt.Errorf("Error: %s already exists.  Please delete it and try again.", filename)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	ctx, cancel = context.WithTimeout(context.TODO(), 15*time.Minute)
+	defer cancel()
+
+	err = discoverInstallConfigInputs(ctx, &inputs, *ptrNetworkName, *ptrBastionName)
+	if err != nil {
+		return err
+	}
+
+	content, err = marshalInstallConfig(newInstallConfig(inputs))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(*ptrDirectory, 0750)
+	if err != nil {
+		return err
+	}
+
+	// The pull secret is in it.
+	err = os.WriteFile(filename, content, 0600)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s\n", filename)
+
+	return nil
+}
+
+// discoverInstallConfigInputs checks the flavor, image and availability zone, and looks up the
+// subnet of the network and the IP address of the bastion.
+func discoverInstallConfigInputs(ctx context.Context, inputs *installConfigInputs, networkName string, bastionName string) error {
+	var (
+		flavor        flavors.Flavor
+		image         images.Image
+		zone          availabilityzones.AvailabilityZone
+		network       networks.Network
+		subnet        *subnets.Subnet
+		bastionServer servers.Server
+		err           error
+	)
+
+	flavor, err = findFlavor(ctx, inputs.Cloud, inputs.FlavorName)
+	if err != nil {
+		return err
+	}
+	log.Debugf("discoverInstallConfigInputs: flavor = %s, %d vCPUs, %d MB", flavor.Name, flavor.VCPUs, flavor.RAM)
+	if flavor.VCPUs < minimumControlPlaneVCPUs || flavor.RAM < minimumControlPlaneRAM {
+		return fmt.Errorf("Error: Flavor %s has %d vCPUs and %d MB memory, a control plane node needs at least %d vCPUs and %d MB", flavor.Name, flavor.VCPUs, flavor.RAM, minimumControlPlaneVCPUs, minimumControlPlaneRAM)
+	}
+
+	image, err = findImage(ctx, inputs.Cloud, inputs.ImageName)
+	if err != nil {
+		return err
+	}
+	if image.Status != images.ImageStatusActive {
+		return fmt.Errorf("Error: Image %s is %s, not active", image.Name, image.Status)
+	}
+
+	zone, err = findAvailabilityZone(ctx, inputs.Cloud, inputs.AvailabilityZone)
+	if err != nil {
+		return err
+	}
+	if !zone.ZoneState.Available {
+		return fmt.Errorf("Error: Availability zone %s is not available", zone.ZoneName)
+	}
+
+	network, err = findNetwork(ctx, inputs.Cloud, networkName)
+	if err != nil {
+		return err
+	}
+
+	for _, subnetID := range network.Subnets {
+		subnet, err = getSubnet(ctx, inputs.Cloud, subnetID)
+		if err != nil {
+			return err
+		}
+		log.Debugf("discoverInstallConfigInputs: subnet = %s, CIDR = %s, IP version = %d", subnet.ID, subnet.CIDR, subnet.IPVersion)
+
+		if subnet.IPVersion == 4 {
+			inputs.SubnetID = subnet.ID
+			inputs.MachineCIDR = subnet.CIDR
+			break
+		}
+	}
+	if inputs.SubnetID == "" {
+		return fmt.Errorf("Error: Network %s has no IPv4 subnet", network.Name)
+	}
+
+	bastionServer, err = findServer(ctx, inputs.Cloud, bastionName)
+	if err != nil {
+		return err
+	}
+
+	_, inputs.VIP, err = findIpAddress(bastionServer)
+	if err != nil {
+		return err
+	}
+	if inputs.VIP == "" {
+		return fmt.Errorf("Error: Bastion %s has no IP address", bastionName)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func preflightCommand(preflightFlags *flag.FlagSet, args []string) error {
+	var (
+		out                  io.Writer
+		ptrCloud             *string
+		ptrFlavorName        *string
+		ptrCount             *string
+		ptrPlannedVMs        *string
+		ptrPlannedFlavorName *string
+		ptrShouldDebug       *string
+		count                int
+		requests             []preflightRequest
+		ctx                  context.Context
+		cancel               context.CancelFunc
+		err                  error
+	)
+
+	ptrCloud = preflightFlags.String("cloud", "", "The cloud to use in clouds.yaml")
+	ptrFlavorName = preflightFlags.String("flavorName", "", "The name of the flavor to use")
+	ptrCount = preflightFlags.String("count", "1", "How many VMs of the flavor to create")
+	ptrPlannedVMs, ptrPlannedFlavorName = addPlannedFlags(preflightFlags)
+	ptrShouldDebug = preflightFlags.String("shouldDebug", "false", "Should output debug output")
+
+	addProfileFlags(preflightFlags)
+
+	preflightFlags.Parse(args)
+
+	err = applyProfile(preflightFlags)
+	if err != nil {
+		return err
+	}
+
+	if ptrCloud == nil || *ptrCloud == "" {
+		return fmt.Errorf("Error: --cloud not specified")
+	}
+	if ptrFlavorName == nil || *ptrFlavorName == "" {
+		return fmt.Errorf("Error: --flavorName not specified")
+	}
+
+	count, err = strconv.Atoi(*ptrCount)
+	if err != nil || count < 0 {
+		return fmt.Errorf("Error: count is not a number (%s)\n", *ptrCount)
+	}
+
+	requests, err = preflightRequests(*ptrFlavorName, count, *ptrPlannedVMs, *ptrPlannedFlavorName)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(*ptrShouldDebug) {
+	case "true":
+		shouldDebug = true
+	case "false":
+		shouldDebug = false
+	default:
+		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
+	}
+
+	if shouldDebug {
+		out = os.Stderr
+	} else {
+		out = io.Discard
+	}
+	log = &logrus.Logger{
+		Out:       out,
+		Formatter: new(logrus.TextFormatter),
+		Level:     logrus.DebugLevel,
+	}
+
+	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
+
+	ctx, cancel = context.WithTimeout(context.TODO(), 15*time.Minute)
+	defer cancel()
+
+	err = runPreflight(ctx, *ptrCloud, requests, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Preflight passed")
+
+	return nil
+}
@@ -30,6 +30,7 @@ func sendMetadataCommand(sendMetadataFlags *flag.FlagSet, args []string) error {
 		ptrCreateMetadata    *string
 		ptrDeleteMetadata    *string
 		ptrServerIP          *string
+		ptrCertDirectory     *string
 		ptrShouldDebug       *string
 		shouldCreateMetadata bool
 		shouldDeleteMetadata bool
@@ -40,10 +41,18 @@ func sendMetadataCommand(sendMetadataFlags *flag.FlagSet, args []string) error {
 	ptrCreateMetadata = sendMetadataFlags.String("createMetadata", "", "Create the metadata of this file")
 	ptrDeleteMetadata = sendMetadataFlags.String("deleteMetadata", "", "Delete the metadata of this file")
 	ptrServerIP = sendMetadataFlags.String("serverIP", "", "The IP address of the server to send the command to")
+	ptrCertDirectory = addCertDirectoryFlag(sendMetadataFlags)
 	ptrShouldDebug = sendMetadataFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(sendMetadataFlags)
+
 	sendMetadataFlags.Parse(args)
 
+	err = applyProfile(sendMetadataFlags)
+	if err != nil {
+		return err
+	}
+
 	if ptrCreateMetadata != nil && *ptrCreateMetadata != "" {
 		shouldCreateMetadata = true
 		metadataFile = *ptrCreateMetadata
@@ -82,7 +91,7 @@ func sendMetadataCommand(sendMetadataFlags *flag.FlagSet, args []string) error {
 
 	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
 
-	err = sendMetadata(metadataFile, *ptrServerIP, shouldCreateMetadata)
+	err = sendMetadata(metadataFile, *ptrServerIP, *ptrCertDirectory, shouldCreateMetadata)
 
 	return err
 }
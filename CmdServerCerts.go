@@ -0,0 +1,136 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func serverCertsCommand(serverCertsFlags *flag.FlagSet, args []string) error {
+	var (
+		out              io.Writer
+		ptrCertDirectory *string
+		ptrHosts         *string
+		ptrValidity      *string
+		ptrOverwrite     *string
+		ptrShouldDebug   *string
+		overwrite        = false
+		validity         time.Duration
+		hosts            []string
+		err              error
+	)
+
+	ptrCertDirectory = addCertDirectoryFlag(serverCertsFlags)
+	ptrHosts = serverCertsFlags.String("hosts", "", "A comma separated list of the IP addresses and hostnames of the watch-installation server")
+	ptrValidity = serverCertsFlags.String("validity", "8760h", "How long the certificates are valid for")
+	ptrOverwrite = serverCertsFlags.String("overwrite", "false", "Replace existing certificates")
+	ptrShouldDebug = serverCertsFlags.String("shouldDebug", "false", "Should output debug output")
+
+	addProfileFlags(serverCertsFlags)
+
+	serverCertsFlags.Parse(args)
+
+	err = applyProfile(serverCertsFlags)
+	if err != nil {
+		return err
+	}
+
+	if ptrCertDirectory == nil || *ptrCertDirectory == "" {
+		return fmt.Errorf("Error: --certDirectory not specified")
+	}
+	for _, host := range strings.Split(*ptrHosts, ",") {
+		host = strings.TrimSpace(host)
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("Error: --hosts not specified")
+	}
+
+	validity, err = time.ParseDuration(*ptrValidity)
+	if err != nil {
+		return fmt.Errorf("Error: validity is not a duration (%s): %v\n", *ptrValidity, err)
+	}
+
+	switch strings.ToLower(*ptrOverwrite) {
+	case "true":
+		overwrite = true
+	case "false":
+		overwrite = false
+	default:
+		return fmt.Errorf("Error: overwrite is not true/false (%s)\n", *ptrOverwrite)
+	}
+
+	switch strings.ToLower(*ptrShouldDebug) {
+	case "true":
+		shouldDebug = true
+	case "false":
+		shouldDebug = false
+	default:
+		return fmt.Errorf("Error: shouldDebug is not true/false (%s)\n", *ptrShouldDebug)
+	}
+
+	if shouldDebug {
+		out = os.Stderr
+	} else {
+		out = io.Discard
+	}
+	log = &logrus.Logger{
+		Out:       out,
+		Formatter: new(logrus.TextFormatter),
+		Level:     logrus.DebugLevel,
+	}
+
+	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
+
+	// A new CA invalidates every client certificate already handed out
+	_, err = os.Stat(filepath.Join(*ptrCertDirectory, caCertFilename))
+	if err == nil && !overwrite {
+		return fmt.Errorf("Error: %s already exists, use --overwrite true to replace it", filepath.Join(*ptrCertDirectory, caCertFilename))
+	}
+
+	log.Debugf("serverCertsCommand: certDirectory = %s, hosts = %v, validity = %v", *ptrCertDirectory, hosts, validity)
+
+	err = generateCertificates(*ptrCertDirectory, hosts, validity)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s, %s, %s, %s, %s and %s to %s\n",
+		caCertFilename,
+		caKeyFilename,
+		serverCertFilename,
+		serverKeyFilename,
+		clientCertFilename,
+		clientKeyFilename,
+		*ptrCertDirectory,
+	)
+	fmt.Printf("Copy %s, %s and %s to the machines which run check-alive, create-bastion and send-metadata.\n",
+		caCertFilename,
+		clientCertFilename,
+		clientKeyFilename,
+	)
+
+	return nil
+}
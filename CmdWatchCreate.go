@@ -20,11 +20,18 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	defaultWatchInterval = 30 * time.Second
+)
+
 func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []string) error {
 	var (
 		out                io.Writer
@@ -36,25 +43,28 @@ func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []str
 		ptrBastionRsa      *string
 		ptrBaseDomain      *string
 		ptrCisInstanceCRN  *string
+		ptrResolver        *string
+		ptrOutput          *string
+		ptrWorkers         *string
+		ptrCheckTimeout    *string
+		ptrInterval        *string
+		ptrUntilHealthy    *string
 		ptrShouldDebug     *string
 		metadata           *Metadata
 		services           *Services
 		robjsFuncs         []NewRunnableObjectsEntry
 		robjsCluster       []RunnableObject
+		unknowns           []ObjectReport
+		failed             []NewRunnableObjectsEntry
+		workers            int
+		checkTimeout       time.Duration
+		interval           time.Duration
+		untilHealthy       = false
 		robjObjectName     string
+		report             *Report
 		err                error
 	)
 
-	// ibmcloud is optional
-	apiKey = os.Getenv("IBMCLOUD_API_KEY")
-	if len(apiKey) != 0 {
-		// Before we do a lot of work, validate the apikey!
-		_, err = InitBXService(apiKey)
-		if err != nil {
-			return err
-		}
-	}
-
 	ptrCloud = watchCreateClusterFlags.String("cloud", "", "The cloud to use in clouds.yaml")
 	ptrMetadata = watchCreateClusterFlags.String("metadata", "", "The location of the metadata.json file")
 	ptrKubeConfig = watchCreateClusterFlags.String("kubeconfig", "", "The KUBECONFIG file")
@@ -62,10 +72,33 @@ func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []str
 	ptrBastionRsa = watchCreateClusterFlags.String("bastionRsa", "", "The RSA filename for the bastion VM to use")
 	ptrBaseDomain = watchCreateClusterFlags.String("baseDomain", "", "The DNS base name to use")
 	ptrCisInstanceCRN = watchCreateClusterFlags.String("cisInstanceCRN", "", "The IBMCloud DNS CRN to use")
+	ptrResolver = watchCreateClusterFlags.String("resolver", "", "The DNS server to resolve the cluster names with, instead of /etc/resolv.conf")
+	ptrOutput = watchCreateClusterFlags.String("output", OutputText, "The format of the report: text, json or junit")
+	ptrWorkers = watchCreateClusterFlags.String("workers", strconv.Itoa(defaultCheckWorkers), "How many checks to run at the same time")
+	ptrCheckTimeout = watchCreateClusterFlags.String("checkTimeout", defaultCheckTimeout.String(), "How long one check may take")
+	ptrInterval = watchCreateClusterFlags.String("interval", "0s", "Check the cluster again after this long and print what changed (0 checks once)")
+	ptrUntilHealthy = watchCreateClusterFlags.String("untilHealthy", "false", "Keep checking the cluster until it is healthy")
 	ptrShouldDebug = watchCreateClusterFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(watchCreateClusterFlags)
+
 	watchCreateClusterFlags.Parse(args)
 
+	err = applyProfile(watchCreateClusterFlags)
+	if err != nil {
+		return err
+	}
+
+	// ibmcloud is optional
+	apiKey = os.Getenv("IBMCLOUD_API_KEY")
+	if len(apiKey) != 0 {
+		// Before we do a lot of work, validate the apikey!
+		_, err = InitBXService(apiKey)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch strings.ToLower(*ptrShouldDebug) {
 	case "true":
 		shouldDebug = true
@@ -98,6 +131,40 @@ func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []str
 	if ptrBastionRsa == nil || *ptrBastionRsa == "" {
 		return fmt.Errorf("Error: --bastionRsa not specified")
 	}
+	if !validOutputFormat(*ptrOutput) {
+		return fmt.Errorf("Error: output is not text/json/junit (%s)\n", *ptrOutput)
+	}
+
+	workers, err = strconv.Atoi(*ptrWorkers)
+	if err != nil || workers < 1 {
+		return fmt.Errorf("Error: workers is not a positive number (%s)\n", *ptrWorkers)
+	}
+
+	checkTimeout, err = time.ParseDuration(*ptrCheckTimeout)
+	if err != nil || checkTimeout <= 0 {
+		return fmt.Errorf("Error: checkTimeout is not a duration (%s)\n", *ptrCheckTimeout)
+	}
+
+	interval, err = time.ParseDuration(*ptrInterval)
+	if err != nil || interval < 0 {
+		return fmt.Errorf("Error: interval is not a duration (%s)\n", *ptrInterval)
+	}
+
+	switch strings.ToLower(*ptrUntilHealthy) {
+	case "true":
+		untilHealthy = true
+	case "false":
+		untilHealthy = false
+	default:
+		return fmt.Errorf("Error: untilHealthy is not true/false (%s)\n", *ptrUntilHealthy)
+	}
+
+	if untilHealthy && interval == 0 {
+		interval = defaultWatchInterval
+	}
+	if interval != 0 && *ptrOutput != OutputText {
+		return fmt.Errorf("Error: --interval only prints text, not %s", *ptrOutput)
+	}
 
 	_, err = ioutil.ReadFile(*ptrMetadata)
 	if err != nil {
@@ -110,24 +177,32 @@ func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []str
 	}
 	robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewVMs,          "Virtual Machines"})
 	robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewLoadBalancer, "Load Balancer"})
+	robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewIgnition,     "Bootstrap Ignition"})
+	robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewPorts,        "Network Ports"})
 	if *ptrBaseDomain != "" && *ptrCisInstanceCRN != "" {
 		robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewIBMDNS, "IBM Domain Name Service"})
 	}
+	if *ptrBaseDomain != "" {
+		robjsFuncs = append(robjsFuncs, NewRunnableObjectsEntry{NewDNSResolution, "Domain Name Resolution"})
+	}
 
 	fmt.Fprintf(os.Stderr, "Program version is %v, release = %v\n", version, release)
 
+	// Keep stdout for the report
+	sshEcho = os.Stderr
+
 	metadata, err = NewMetadataFromCCMetadata(*ptrMetadata)
 	if err != nil {
 		return fmt.Errorf("Error: Could not read metadata from %s\n", *ptrMetadata)
 	}
 	log.Debugf("metadata = %+v", metadata)
 
-	services, err = NewServices(metadata, apiKey, *ptrKubeConfig, *ptrCloud, *ptrBastionUsername, *ptrBastionRsa, *ptrBaseDomain, *ptrCisInstanceCRN)
+	services, err = NewServices(metadata, filepath.Dir(*ptrMetadata), apiKey, *ptrKubeConfig, *ptrCloud, *ptrBastionUsername, *ptrBastionRsa, *ptrBaseDomain, *ptrCisInstanceCRN, *ptrResolver)
 	if err != nil {
 		return fmt.Errorf("Error: Could not create a Services object (%s)!\n", err)
 	}
 
-	robjsCluster, err = initializeRunnableObjects(services, robjsFuncs)
+	robjsCluster, unknowns, failed, err = initializeRunnableObjects(services, robjsFuncs)
 	if err != nil {
 		return err
 	}
@@ -140,9 +215,101 @@ func watchCreateClusterCommand(watchCreateClusterFlags *flag.FlagSet, args []str
 	}
 	fmt.Fprintf(os.Stderr, "Sorted the objects.\n")
 
+	if interval != 0 {
+		return watchCluster(metadata.GetInfraID(), services, robjsCluster, unknowns, failed, workers, checkTimeout, interval, untilHealthy)
+	}
+
 	// Query the status of the objects.
-	for _, robj := range robjsCluster {
-		robj.ClusterStatus()
+	report, err = checkCluster(metadata.GetInfraID(), robjsCluster, unknowns, workers, checkTimeout)
+	if err != nil {
+		return err
+	}
+
+	err = report.Write(os.Stdout, *ptrOutput)
+	if err != nil {
+		return err
+	}
+
+	if !report.Healthy() {
+		return fmt.Errorf("Error: The cluster is %s (%d findings failed)", report.Verdict, report.Failures)
+	}
+
+	return nil
+}
+
+// checkCluster runs every check once.  unknowns are the objects which could not be created.
+func checkCluster(infraID string, robjsCluster []RunnableObject, unknowns []ObjectReport, workers int, checkTimeout time.Duration) (*Report, error) {
+	var (
+		report        = newReport(infraID)
+		objectReports []ObjectReport
+		err           error
+	)
+
+	for _, objectReport := range unknowns {
+		report.addObjectReport(objectReport)
+	}
+
+	objectReports, err = runChecks(robjsCluster, workers, checkTimeout)
+	if err != nil {
+		return nil, err
+	}
+	for _, objectReport := range objectReports {
+		report.addObjectReport(objectReport)
+	}
+
+	return report, nil
+}
+
+// watchCluster checks the cluster every interval and only prints what changed.  With
+// untilHealthy it stops once the cluster is healthy and prints a timeline of the changes.  The
+// objects which failed to be created or run are tried again before every check after the first.
+func watchCluster(infraID string, services *Services, robjsCluster []RunnableObject, unknowns []ObjectReport, failed []NewRunnableObjectsEntry, workers int, checkTimeout time.Duration, interval time.Duration, untilHealthy bool) error {
+	var (
+		start    = time.Now()
+		report   *Report
+		robjsNew []RunnableObject
+		previous map[string]string
+		current  map[string]string
+		changes  []Transition
+		timeline []Transition
+		initial  string
+		err      error
+	)
+
+	for true {
+		if previous != nil && len(failed) > 0 {
+			robjsNew, unknowns, failed, err = initializeRunnableObjects(services, failed)
+			if err != nil {
+				return err
+			}
+			robjsCluster = BubbleSort(append(robjsCluster, robjsNew...))
+		}
+
+		report, err = checkCluster(infraID, robjsCluster, unknowns, workers, checkTimeout)
+		if err != nil {
+			return err
+		}
+
+		current = reportStates(report)
+
+		// The first check is where the timeline starts, not a change
+		if previous == nil {
+			initial = report.Verdict.String()
+			writeStates(os.Stdout, current, time.Now())
+		} else {
+			changes = diffStates(previous, current, time.Now())
+			writeTransitions(os.Stdout, changes)
+			timeline = append(timeline, changes...)
+		}
+		previous = current
+
+		if untilHealthy && report.Healthy() {
+			writeTimeline(os.Stdout, start, initial, timeline, time.Now(), report.Verdict.String())
+			return nil
+		}
+
+		log.Debugf("watchCluster: Sleeping for %v", interval)
+		time.Sleep(interval)
 	}
 
 	return nil
@@ -17,7 +17,9 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -102,8 +104,16 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 		ptrDhcpDnsServers   *string
 		ptrDhcpServerId     *string
 		ptrEnableDhcpd      *string
+		ptrCertDirectory    *string
+		ptrHttpListen       *string
+		ptrStateDir         *string
 		ptrShouldDebug      *string
 		enableDhcpd         = false
+		tlsConfig           *tls.Config
+		state               = newWatchState()
+		saved               *savedWatchState
+		forceReconcile      = false
+		loopStart           time.Time
 		ctx                 context.Context
 		cancel              context.CancelFunc
 		knownServers        = sets.Set[string]{}
@@ -115,8 +125,6 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 		err                 error
 	)
 
-	apiKey = os.Getenv("IBMCLOUD_API_KEY")
-
 	ptrCloud = watchInstallationFlags.String("cloud", "", "The cloud to use in clouds.yaml")
 	ptrDomainName = watchInstallationFlags.String("domainName", "", "The DNS domain to use")
 	ptrBastionMetadata = watchInstallationFlags.String("bastionMetadata", "", "A root directory where OpenShift clusters installs are located")
@@ -129,10 +137,22 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 	ptrDhcpRouter = watchInstallationFlags.String("dhcpRouter", "", "The router for a DHCP request")
 	ptrDhcpDnsServers = watchInstallationFlags.String("dhcpDnsServers",  "", "The DNS servers for a DHCP request")
 	ptrDhcpServerId = watchInstallationFlags.String("dhcpServerId",  "", "The DNS server identifier for a DHCP request")
+	ptrCertDirectory = addCertDirectoryFlag(watchInstallationFlags)
+	ptrHttpListen = watchInstallationFlags.String("httpListen", ":8443", "The address for the HTTP API to listen on (empty disables it)")
+	ptrStateDir = watchInstallationFlags.String("stateDir", "", "The directory to save the known servers in between restarts (empty disables it)")
 	ptrShouldDebug = watchInstallationFlags.String("shouldDebug", "false", "Should output debug output")
 
+	addProfileFlags(watchInstallationFlags)
+
 	watchInstallationFlags.Parse(args)
 
+	err = applyProfile(watchInstallationFlags)
+	if err != nil {
+		return err
+	}
+
+	apiKey = os.Getenv("IBMCLOUD_API_KEY")
+
 	if ptrCloud == nil || *ptrCloud == "" {
 		return fmt.Errorf("Error: --cloud not specified")
 	}
@@ -200,20 +220,47 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 
 	bastionRsa = *ptrBastionRsa
 
+	// Only clients with a certificate from server-certs may send commands
+	tlsConfig, err = newServerTLSConfig(*ptrCertDirectory)
+	if err != nil {
+		return err
+	}
+
+	// Pick up where the last run left off so changes made while we were down are not missed
+	if *ptrStateDir != "" {
+		saved, err = loadWatchState(*ptrStateDir)
+		if err != nil {
+			return err
+		}
+	}
+	if saved != nil {
+		fmt.Fprintf(os.Stderr, "Loaded %d known servers saved at %v from %s\n", len(saved.KnownServers), saved.Saved, *ptrStateDir)
+
+		knownServers = sets.New(saved.KnownServers...)
+		firstDnsRun = saved.FirstDnsRun
+		state.setBastionInformations(saved.BastionInformations)
+		state.setLastReconcile(saved.Saved)
+	}
+
 	ctx, cancel = context.WithTimeout(context.TODO(), 5*time.Minute)
 	defer cancel()
 
 	// Spawn off the metadata listeners
-	go listenForCommands(*ptrCloud)
+	go listenForCommands(*ptrCloud, tlsConfig)
+	if *ptrHttpListen != "" {
+		go listenForHTTP(*ptrHttpListen, *ptrCloud, tlsConfig, state)
+	}
 
 	for true {
 		log.Debugf("Waking up")
+		loopStart = time.Now()
 
 		bastionInformations, err = gatherBastionInformations(*ptrBastionMetadata, *ptrBastionUsername, *ptrBastionRsa)
 		if err != nil {
 			return err
 		}
 		log.Debugf("bastionInformations [%d] = %+v", len(bastionInformations), bastionInformations)
+		state.setBastionInformations(bastionInformations)
 
 		ctx, cancel = context.WithTimeout(context.TODO(), 24*time.Hour)
 		defer cancel()
@@ -232,13 +279,16 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 		log.Debugf("deletedServerSet = %+v", deletedServerSet)
 
 		// If we haven't added new servers or deleted old servers, then try again
-		if addedServersSet.Len() == 0 && deletedServerSet.Len() == 0 {
+		if addedServersSet.Len() == 0 && deletedServerSet.Len() == 0 && !forceReconcile {
+			observeLoopIteration(loopStart, false)
+
 			log.Debugf("Sleeping")
 
-			time.Sleep(30 * time.Second)
+			forceReconcile = state.sleep(30 * time.Second)
 
 			continue
 		}
+		forceReconcile = false
 
 		knownServers = newServerSet
 
@@ -248,6 +298,8 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 		if err != nil {
 			return err
 		}
+		setClusterServers(bastionInformations)
+		state.setBastionInformations(bastionInformations)
 
 		fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
 
@@ -285,6 +337,7 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 				"restart",
 				"dhcpd.service",
 			})
+			dhcpdReloads.WithLabelValues(metricsResult(err)).Inc()
 			if err != nil {
 				return err
 			}
@@ -312,9 +365,20 @@ func watchInstallationCommand(watchInstallationFlags *flag.FlagSet, args []strin
 			return err
 		}
 
+		state.setLastReconcile(time.Now())
+
+		if *ptrStateDir != "" {
+			err = saveWatchState(*ptrStateDir, knownServers, bastionInformations)
+			if err != nil {
+				return err
+			}
+		}
+
+		observeLoopIteration(loopStart, true)
+
 		log.Debugf("Sleeping")
 
-		time.Sleep(30 * time.Second)
+		forceReconcile = state.sleep(30 * time.Second)
 	}
 
 	return nil
@@ -634,8 +698,7 @@ func dhcpdConf(ctx context.Context, filename string, cloud string, domainName st
 func haproxyCfg(ctx context.Context, cloud string, bastionInformations []bastionInformation) error {
 	var (
 		allServers []servers.Server
-		server      servers.Server
-		err         error
+		err        error
 	)
 
 	allServers, err = getAllServers(ctx, cloud)
@@ -652,9 +715,9 @@ func haproxyCfg(ctx context.Context, cloud string, bastionInformations []bastion
 
 	for _, bastionInformation := range bastionInformations {
 		var (
-			file        *os.File
-			filename    string
-			prefixMatch string
+			file     *os.File
+			filename string
+			backends []haproxyBackend
 		)
 
 		log.Debugf("haproxyCfg: bastionInformation = %+v", bastionInformation)
@@ -690,107 +753,49 @@ func haproxyCfg(ctx context.Context, cloud string, bastionInformations []bastion
 		fmt.Fprintf(file, "timeout server 50s\n")
 		fmt.Fprintf(file, "\n")
 		fmt.Fprintf(file, "listen stats # Define a listen section called \"stats\"\n")
-		fmt.Fprintf(file, "  bind :9000 # Listen on localhost:9000\n")
+		fmt.Fprintf(file, "  bind :%d # Listen on localhost:%d\n", haproxyStatsPort, haproxyStatsPort)
 		fmt.Fprintf(file, "  mode http\n")
 		fmt.Fprintf(file, "  stats enable  # Enable stats page\n")
 		fmt.Fprintf(file, "  stats hide-version  # Hide HAProxy version\n")
 		fmt.Fprintf(file, "  stats realm Haproxy\\ Statistics  # Title text for popup window\n")
-		fmt.Fprintf(file, "  stats uri /haproxy_stats  # Stats URI\n")
-		fmt.Fprintf(file, "  stats auth Username:Password  # Authentication credentials\n")
-		fmt.Fprintf(file, "\n")
-
-		// listen ingress-http
-		fmt.Fprintf(file, "listen ingress-http\n")
-		fmt.Fprintf(file, "bind *:80\n")
-		fmt.Fprintf(file, "mode tcp\n")
-		prefixMatch = fmt.Sprintf("%s-worker-", bastionInformation.InfraID)
-		for _, server = range allServers {
-			if !strings.HasPrefix(strings.ToLower(server.Name), prefixMatch) {
-				continue
-			}
-	
-			macAddr, ipAddress, err := findIpAddress(server)
-			if err == nil && macAddr != "" && ipAddress != "" {
-				fmt.Fprintf(file, "server %s %s:80 check\n", server.Name, ipAddress)
-			}
-		}
+		fmt.Fprintf(file, "  stats uri %s  # Stats URI\n", haproxyStatsURI)
+		fmt.Fprintf(file, "  stats auth %s:%s  # Authentication credentials\n", haproxyStatsUsername, haproxyStatsPassword)
 		fmt.Fprintf(file, "\n")
 
-		// listen ingress-https
-		fmt.Fprintf(file, "listen ingress-https\n")
-		fmt.Fprintf(file, "bind *:443\n")
-		fmt.Fprintf(file, "mode tcp\n")
-		prefixMatch = fmt.Sprintf("%s-worker-", bastionInformation.InfraID)
-		for _, server = range allServers {
-			if !strings.HasPrefix(strings.ToLower(server.Name), prefixMatch) {
-				continue
-			}
-
-			macAddr, ipAddress, err := findIpAddress(server)
-			if err == nil && macAddr != "" && ipAddress != "" {
-				fmt.Fprintf(file, "server %s %s:443 check\n", server.Name, ipAddress)
-			}
-		}
-		fmt.Fprintf(file, "\n")
-
-		// listen api
-		fmt.Fprintf(file, "listen api\n")
-		fmt.Fprintf(file, "bind *:6443\n")
-		fmt.Fprintf(file, "mode tcp\n")
-		for _, server = range allServers {
-			if !strings.HasPrefix(strings.ToLower(server.Name), bastionInformation.InfraID) {
-				continue
-			}
-			if !(strings.Contains(strings.ToLower(server.Name), "bootstrap") || strings.Contains(strings.ToLower(server.Name), "master")) {
-				continue
-			}
+		backends = haproxyBackends(allServers, bastionInformation.InfraID)
+		for _, proxy := range haproxyProxies {
+			fmt.Fprintf(file, "listen %s\n", proxy.Name)
+			fmt.Fprintf(file, "bind *:%d\n", proxy.Port)
+			fmt.Fprintf(file, "mode tcp\n")
+			for _, backend := range backends {
+				if backend.Proxy != proxy.Name {
+					continue
+				}
 
-			macAddr, ipAddress, err := findIpAddress(server)
-			if err == nil && macAddr != "" && ipAddress != "" {
-				fmt.Fprintf(file, "server %s %s:6443 check\n", server.Name, ipAddress)
+				fmt.Fprintf(file, "server %s %s check\n", backend.Server, backend.Address)
 			}
+			fmt.Fprintf(file, "\n")
 		}
-		fmt.Fprintf(file, "\n")
-
-		// listen machine-config-server
-		fmt.Fprintf(file, "listen machine-config-server\n")
-		fmt.Fprintf(file, "bind *:22623\n")
-		fmt.Fprintf(file, "mode tcp\n")
-		for _, server = range allServers {
-			if !strings.HasPrefix(strings.ToLower(server.Name), bastionInformation.InfraID) {
-				continue
-			}
-			if !(strings.Contains(strings.ToLower(server.Name), "bootstrap") || strings.Contains(strings.ToLower(server.Name), "master")) {
-				continue
-			}
 
-			macAddr, ipAddress, err := findIpAddress(server)
-			if err == nil && macAddr != "" && ipAddress != "" {
-				fmt.Fprintf(file, "server %s %s:22623 check\n", server.Name, ipAddress)
-			}
+		target := sshTarget{
+			Host:        bastionInformation.IPAddress,
+			Username:    bastionInformation.Username,
+			KeyFilename: bastionInformation.InstallerRsa,
 		}
 
-		err = runSplitCommand([]string{
-			"scp",
-			"-i",
-			bastionInformation.InstallerRsa,
-			filename,
-			fmt.Sprintf("%s@%s:/etc/haproxy/haproxy.cfg", bastionInformation.Username, bastionInformation.IPAddress),
-		})
+		err = sftpPut(ctx, target, filename, "/etc/haproxy/haproxy.cfg")
+		haproxyPushes.WithLabelValues(bastionInformation.ClusterName, metricsResult(err)).Inc()
 		if err != nil {
 			return err
 		}
 
-		err = runSplitCommand([]string{
-			"ssh",
-			"-i",
-			bastionInformation.InstallerRsa,
-			fmt.Sprintf("%s@%s", bastionInformation.Username, bastionInformation.IPAddress),
+		_, err = sshRun(ctx, target, []string{
 			"sudo",
 			"systemctl",
 			"restart",
 			"haproxy.service",
 		})
+		haproxyRestarts.WithLabelValues(bastionInformation.ClusterName, metricsResult(err)).Inc()
 		if err != nil {
 			return err
 		}
@@ -1019,6 +1024,33 @@ func loadDnsServiceAPI(apiKey string, crnstr string, zoneID string)(service *dns
 	return
 }
 
+// loadDnsServiceForDomain finds the CIS instance which owns domainName and returns a DNS records service for it.
+func loadDnsServiceForDomain(ctx context.Context, apiKey string, domainName string) (dnsService *dnsrecordsv1.DnsRecordsV1, err error) {
+	var (
+		cisServiceID string
+		crnstr       string
+		zoneID       string
+	)
+
+	cisServiceID, _, err = getServiceInfo(ctx, apiKey, "internet-svcs", "")
+	if err != nil {
+		log.Errorf("getServiceInfo returns %v", err)
+		return
+	}
+	log.Debugf("loadDnsServiceForDomain: cisServiceID = %s", cisServiceID)
+
+	crnstr, zoneID, err = getDomainCrn(ctx, apiKey, cisServiceID, domainName)
+	log.Debugf("loadDnsServiceForDomain: crnstr = %s, zoneID = %s, err = %+v", crnstr, zoneID, err)
+	if err != nil {
+		log.Errorf("getDomainCrn returns %v", err)
+		return
+	}
+
+	dnsService, err = loadDnsServiceAPI(apiKey, crnstr, zoneID)
+
+	return
+}
+
 // getServiceInfo retrieving id info of given service and service plan
 func getServiceInfo(ctx context.Context, apiKey string, service string, servicePlan string) (string, string, error) {
 	var (
@@ -1231,6 +1263,7 @@ func createOrDeletePublicDNSRecord(ctx context.Context, dnsRecordType string, ho
 
 	foundRecordID, content, err = findDNSRecord(ctx, dnsService, hostname)
 	if err != nil {
+		dnsRecordFailures.WithLabelValues(dnsRecordType, "find").Inc()
 		return err
 	}
 	log.Debugf("createOrDeletePublicDNSRecord: foundRecordID = %s, content = %s", foundRecordID, content)
@@ -1246,6 +1279,7 @@ func createOrDeletePublicDNSRecord(ctx context.Context, dnsRecordType string, ho
 
 			result, response, err := deleteDnsRecord(ctx, dnsService, deleteOptions)
 			if err != nil {
+				dnsRecordFailures.WithLabelValues(dnsRecordType, "delete").Inc()
 				return fmt.Errorf("DeleteDnsRecordWithContext response = %+v, err = %+v", response, err)
 			}
 
@@ -1254,8 +1288,10 @@ func createOrDeletePublicDNSRecord(ctx context.Context, dnsRecordType string, ho
 					log.Debugf("createOrDeletePublicDNSRecord: aerrmsg = %+v", aerrmsg)
 					// @TODO
 				}
+				dnsRecordFailures.WithLabelValues(dnsRecordType, "delete").Inc()
 				return fmt.Errorf("DeleteDnsRecordWithContext result.Success is false")
 			}
+			dnsRecordOperations.WithLabelValues(dnsRecordType, "delete").Inc()
 		}
 
 		// If we shoud create AND the content is the same, then we are done.
@@ -1278,20 +1314,23 @@ func createOrDeletePublicDNSRecord(ctx context.Context, dnsRecordType string, ho
 
 	result, response, err := createDnsRecord(ctx, dnsService, createOptions)
 	if err != nil {
+		dnsRecordFailures.WithLabelValues(dnsRecordType, "create").Inc()
 		log.Errorf("dnsRecordService.CreateDnsRecordWithContext returns %v", err)
 		return err
 	}
 	log.Debugf("createOrDeletePublicDNSRecord: Result.ID = %v, RawResult = %v", *result.Result.ID, response.RawResult)
+	dnsRecordOperations.WithLabelValues(dnsRecordType, "create").Inc()
 
 	return nil
 }
 
-func listenForCommands(cloud string) error {
+func listenForCommands(cloud string, tlsConfig *tls.Config) error {
 	log.Debugf("listenForCommands")
 
 	// Listen for incoming connections on port 8080
-	ln, err := net.Listen("tcp", ":8080")
+	ln, err := tls.Listen("tcp", ":"+commandPort, tlsConfig)
 	if err != nil {
+		log.Errorf("listenForCommands: tls.Listen returns %v", err)
 		return err
 	}
 
@@ -1309,16 +1348,31 @@ func listenForCommands(cloud string) error {
 
 func handleConnection(conn net.Conn, cloud string) error {
 	var (
-		data      string
-		cmdHeader CommandHeader
-		errChan   chan error
-		result    error
-		err       error
+		data       string
+		cmdHeader  CommandHeader
+		negotiated = protocolVersionLegacy
+		errChan    chan error
+		result     error
+		err        error
 	)
 
 	// Close the connection when we're done
 	defer conn.Close()
 
+	// Reject clients without a valid certificate before reading anything from them
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		err = tlsConn.Handshake()
+		if err != nil {
+			log.Debugf("handleConnection: Handshake() from %s returns %v", conn.RemoteAddr(), err)
+			commandConnections.WithLabelValues("rejected").Inc()
+			return err
+		}
+		for _, cert := range tlsConn.ConnectionState().PeerCertificates {
+			log.Debugf("handleConnection: client %s presented %s", conn.RemoteAddr(), cert.Subject)
+		}
+	}
+	commandConnections.WithLabelValues("accepted").Inc()
+
 	reader := bufio.NewReader(conn)
 
 	for {
@@ -1328,36 +1382,45 @@ func handleConnection(conn net.Conn, cloud string) error {
 			return err
 		}
 
+		cmdHeader = CommandHeader{}
 		err = json.Unmarshal([]byte(data), &cmdHeader)
 		if err != nil {
 			log.Debugf("handleConnection: Unmarshal() returns %v", err)
+			if negotiated >= protocolVersion {
+				sendResult(conn, cmdHeader, "error", StatusBadRequest, err, nil)
+			}
 			return err
 		}
 		log.Debugf("handleConnection: cmdHeader = %+v", cmdHeader)
 
+		if cmdHeader.Command == "hello" {
+			negotiated, err = handleHello(conn, data)
+			if err != nil {
+				return err
+			}
+			log.Debugf("handleConnection: negotiated version %d", negotiated)
+			continue
+		}
+
 		errChan = make(chan error)
 
 		switch cmdHeader.Command {
 		case "check-alive":
-			var (
-				cmd            CommandIsAlive
-				marshalledData []byte
-			)
-
 			go handleCheckAlive(data, errChan)
 			result = <-errChan
 			log.Debugf("handleConnection: result from handleCheckAlive is %v", result)
 
-			cmd.Command = "is-alive"
-			cmd.Result = result
-
-			marshalledData, err = json.Marshal(cmd)
-			if err != nil {
-				log.Debugf("handleConnection: json.Marshal returns %v", err)
-				return err
+			if negotiated >= protocolVersion {
+				err = sendResult(conn, cmdHeader, "is-alive", StatusFailed, result, CheckAliveDetails{
+					Version: version,
+					Release: release,
+				})
+			} else {
+				err = sendJSON(conn, CommandIsAlive{
+					Command: "is-alive",
+					Result:  result,
+				})
 			}
-
-			err = sendByteArray(conn, marshalledData)
 			if err != nil {
 				return err
 			}
@@ -1367,38 +1430,52 @@ func handleConnection(conn net.Conn, cloud string) error {
 			result = <-errChan
 			log.Debugf("handleConnection: result from handleCreateMetadata is %v", result)
 
+			// Version 1 clients do not wait for a response
+			if negotiated >= protocolVersion {
+				err = sendResult(conn, cmdHeader, "metadata-created", metadataStatus(result), result, nil)
+				if err != nil {
+					return err
+				}
+			}
+
 		case "delete-metadata":
 			go handleCreateMetadata(data, false, errChan)
 			result = <-errChan
 			log.Debugf("handleConnection: result from handleCreateMetadata is %v", result)
 
-		case "create-bastion":
-			var (
-				cmd            CommandBastionCreated
-				marshalledData []byte
-			)
+			// Version 1 clients do not wait for a response
+			if negotiated >= protocolVersion {
+				err = sendResult(conn, cmdHeader, "metadata-deleted", metadataStatus(result), result, nil)
+				if err != nil {
+					return err
+				}
+			}
 
+		case "create-bastion":
 			go handleCreateBastion(data, cloud, errChan)
+			log.Debugf("handleConnection: waiting on result from handleCreateBastion")
 			result = <-errChan
 			log.Debugf("handleConnection: result from handleCreateBastion is %v", result)
 
-			log.Debugf("handleConnection: waiting on result from handleCreateBastion")
-			cmd.Command = "bastion-created"
-			cmd.Result = result
-
-			marshalledData, err = json.Marshal(cmd)
-			if err != nil {
-				log.Debugf("handleConnection: json.Marshal returns %v", err)
-				return err
+			if negotiated >= protocolVersion {
+				err = sendResult(conn, cmdHeader, "bastion-created", StatusFailed, result, nil)
+			} else {
+				err = sendJSON(conn, CommandBastionCreated{
+					Command: "bastion-created",
+					Result:  result,
+				})
 			}
-
-			err = sendByteArray(conn, marshalledData)
 			if err != nil {
 				return err
 			}
+
 		default:
 			log.Debugf("handleConnection: ERROR received unknown command %s", cmdHeader.Command)
-			return fmt.Errorf("handleConnection received unknown command %s", cmdHeader.Command)
+			err = fmt.Errorf("handleConnection received unknown command %s", cmdHeader.Command)
+			if negotiated >= protocolVersion {
+				sendResult(conn, cmdHeader, "error", StatusUnknownCommand, err, nil)
+			}
+			return err
 		}
 	}
 
@@ -1408,6 +1485,62 @@ func handleConnection(conn net.Conn, cloud string) error {
 //	return err
 }
 
+// handleHello answers a version 2 client's hello with the version both sides speak.
+func handleHello(conn net.Conn, data string) (int, error) {
+	var (
+		hello      CommandHello
+		negotiated int
+		response   *CommandResponse
+		err        error
+	)
+
+	err = json.Unmarshal([]byte(data), &hello)
+	if err != nil {
+		log.Debugf("handleHello: Unmarshal() returns %v", err)
+		return 0, err
+	}
+
+	negotiated = min(hello.Version, protocolVersion)
+	if negotiated < max(hello.MinVersion, protocolVersionLegacy) {
+		err = fmt.Errorf("protocol version %d-%d is not supported, the server speaks %d-%d",
+			hello.MinVersion,
+			hello.Version,
+			protocolVersionLegacy,
+			protocolVersion,
+		)
+		sendResult(conn, hello.CommandHeader, "hello", StatusVersionUnsupported, err, nil)
+		return 0, err
+	}
+
+	response, err = newResponse(hello.CommandHeader, "hello", StatusOK, "", nil)
+	if err != nil {
+		return 0, err
+	}
+	response.Version = negotiated
+
+	return negotiated, sendJSON(conn, response)
+}
+
+// sendResult sends a version 2 response.  A nil result is StatusOK, otherwise the status is
+// failedStatus and the message is the error verbatim.
+func sendResult(conn net.Conn, header CommandHeader, command string, failedStatus int, result error, details interface{}) error {
+	var (
+		response *CommandResponse
+		err      error
+	)
+
+	if result == nil {
+		response, err = newResponse(header, command, StatusOK, "", details)
+	} else {
+		response, err = newResponse(header, command, failedStatus, result.Error(), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return sendJSON(conn, response)
+}
+
 func handleCheckAlive(data string, errChan chan error) {
 	var (
 		cmd            CommandCheckAlive
@@ -1428,6 +1561,29 @@ func handleCheckAlive(data string, errChan chan error) {
 	return
 }
 
+// errInvalidInfraID is a metadata whose infraID cannot be used as the name of a directory.
+var errInvalidInfraID = errors.New("infraID is not a single DNS label")
+
+// validateInfraID makes sure the infraID, which is used as a relative path, cannot name anything
+// outside the current directory.
+func validateInfraID(infraID string) error {
+	if infraID == "" {
+		return fmt.Errorf("%w: infraID is missing from the metadata", errInvalidInfraID)
+	}
+	if len(infraID) > 63 || !dnsLabelRegexp.MatchString(infraID) || filepath.Base(infraID) != infraID || strings.Contains(infraID, "..") {
+		return fmt.Errorf("%w: %q", errInvalidInfraID, infraID)
+	}
+	return nil
+}
+
+// metadataStatus is the status of a failed create-metadata or delete-metadata.
+func metadataStatus(err error) int {
+	if errors.Is(err, errInvalidInfraID) {
+		return StatusBadRequest
+	}
+	return StatusFailed
+}
+
 func handleCreateMetadata(data string, shouldCreate bool, errChan chan error) {
 	var (
 		cmd            CommandSendMetadata
@@ -1449,6 +1605,13 @@ func handleCreateMetadata(data string, shouldCreate bool, errChan chan error) {
 	log.Debugf("handleCreateMetadata: cmd.metadata.ClusterName = %+v", cmd.Metadata.ClusterName)
 	log.Debugf("handleCreateMetadata: cmd.metadata.InfraID = %+v", cmd.Metadata.InfraID)
 
+	err = validateInfraID(cmd.Metadata.InfraID)
+	if err != nil {
+		log.Debugf("handleCreateMetadata: validateInfraID() returns %v", err)
+		errChan <- err
+		return
+	}
+
 	marshalledData, err = json.Marshal(cmd.Metadata)
 	if err != nil {
 		log.Debugf("handleCreateMetadata: json.Marshal() returns %v", err)
@@ -0,0 +1,261 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// An example ~/.config/powervc-tool/config.yaml:
+//
+//	defaultProfile: lab
+//	profiles:
+//	  lab:
+//	    cloud: powervc-lab
+//	    domainName: example.com
+//	    bastionUsername: cloud-user
+//	    bastionRsa: /home/user/.ssh/id_installer_rsa
+//	    dhcpInterface: env2
+//	    dhcpSubnet: 10.20.176.0
+//	    dhcpNetmask: 255.255.240.0
+//	    dhcpRouter: 10.20.176.1
+//	    dhcpDnsServers: 10.20.176.1, 8.8.8.8
+//	    dhcpServerId: 10.20.176.1
+
+const (
+	configProfileEnv = "POWERVC_TOOL_PROFILE"
+	apiKeyEnv        = "IBMCLOUD_API_KEY"
+	maskedValue      = "********"
+)
+
+// The keys of a Profile are the names of the command line flags they provide a value for.
+type Profile struct {
+	Cloud             string `json:"cloud,omitempty"`
+	DomainName        string `json:"domainName,omitempty"`
+	BaseDomain        string `json:"baseDomain,omitempty"`
+	CisInstanceCRN    string `json:"cisInstanceCRN,omitempty"`
+	Resolver          string `json:"resolver,omitempty"`
+	ServerIP          string `json:"serverIP,omitempty"`
+	CertDirectory     string `json:"certDirectory,omitempty"`
+	StateDir          string `json:"stateDir,omitempty"`
+	BastionUsername   string `json:"bastionUsername,omitempty"`
+	BastionRsa        string `json:"bastionRsa,omitempty"`
+	BastionMetadata   string `json:"bastionMetadata,omitempty"`
+	FlavorName        string `json:"flavorName,omitempty"`
+	Preflight         string `json:"preflight,omitempty"`
+	PlannedVMs        string `json:"plannedVMs,omitempty"`
+	PlannedFlavorName string `json:"plannedFlavorName,omitempty"`
+	ImageName         string `json:"imageName,omitempty"`
+	NetworkName       string `json:"networkName,omitempty"`
+	SshKeyName        string `json:"sshKeyName,omitempty"`
+	EnableHAProxy     string `json:"enableHAProxy,omitempty"`
+	EnableDhcpd       string `json:"enableDhcpd,omitempty"`
+	DhcpInterface     string `json:"dhcpInterface,omitempty"`
+	DhcpSubnet        string `json:"dhcpSubnet,omitempty"`
+	DhcpNetmask       string `json:"dhcpNetmask,omitempty"`
+	DhcpRouter        string `json:"dhcpRouter,omitempty"`
+	DhcpDnsServers    string `json:"dhcpDnsServers,omitempty"`
+	DhcpServerId      string `json:"dhcpServerId,omitempty"`
+	PasswdHash        string `json:"passwdHash,omitempty"`
+	ShouldDebug       string `json:"shouldDebug,omitempty"`
+
+	// Used when the IBMCLOUD_API_KEY environment variable is not set.
+	ApiKey string `json:"apiKey,omitempty"`
+}
+
+type Config struct {
+	DefaultProfile string             `json:"defaultProfile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles"`
+}
+
+var (
+	// Profile keys which are never printed.
+	profileSecrets = []string{"apiKey", "passwdHash"}
+)
+
+func defaultConfigFilename() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(configDir, "powervc-tool", "config.yaml")
+}
+
+// addProfileFlags adds the --config and --profile flags to a command.
+func addProfileFlags(flags *flag.FlagSet) {
+	flags.String("config", defaultConfigFilename(), "The location of the PowerVC-Tool config file")
+	flags.String("profile", "", fmt.Sprintf("The profile to use in the config file (or $%s)", configProfileEnv))
+}
+
+func readConfig(filename string) (*Config, error) {
+	var (
+		content []byte
+		config  Config
+		err     error
+	)
+
+	content, err = os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.UnmarshalStrict(content, &config)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse config file %s: %v", filename, err)
+	}
+
+	return &config, nil
+}
+
+// loadProfile returns the profile selected by --profile, $POWERVC_TOOL_PROFILE, or the config file's
+// defaultProfile, in that order.  A missing config file is only an error when a profile was requested.
+func loadProfile(configFilename string, profileName string) (string, *Profile, error) {
+	var (
+		config  *Config
+		profile Profile
+		ok      bool
+		err     error
+	)
+
+	if profileName == "" {
+		profileName = os.Getenv(configProfileEnv)
+	}
+
+	if configFilename == "" {
+		if profileName != "" {
+			return "", nil, fmt.Errorf("Error: --profile %s specified but there is no config file", profileName)
+		}
+		return "", nil, nil
+	}
+
+	config, err = readConfig(configFilename)
+	if errors.Is(err, fs.ErrNotExist) && profileName == "" {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if profileName == "" {
+		profileName = config.DefaultProfile
+	}
+	if profileName == "" {
+		return "", nil, nil
+	}
+
+	profile, ok = config.Profiles[profileName]
+	if !ok {
+		return "", nil, fmt.Errorf("Error: Could not find profile %s in %s", profileName, configFilename)
+	}
+
+	return profileName, &profile, nil
+}
+
+// profileValues returns the non-empty values of a profile keyed by flag name.
+func profileValues(profile *Profile) (map[string]string, error) {
+	var (
+		content []byte
+		values  map[string]string
+		err     error
+	)
+
+	content, err = json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// applyProfile fills in every flag which was not given on the command line from the selected profile.
+// It must be called right after flags.Parse.
+func applyProfile(flags *flag.FlagSet) error {
+	var (
+		configFilename string
+		profileName    string
+		profile        *Profile
+		values         map[string]string
+		setFlags       = make(map[string]bool)
+		err            error
+	)
+
+	if f := flags.Lookup("config"); f != nil {
+		configFilename = f.Value.String()
+	}
+	if f := flags.Lookup("profile"); f != nil {
+		profileName = f.Value.String()
+	}
+
+	_, profile, err = loadProfile(configFilename, profileName)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return nil
+	}
+
+	values, err = profileValues(profile)
+	if err != nil {
+		return err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	for key, value := range values {
+		if key == "apiKey" {
+			if os.Getenv(apiKeyEnv) == "" {
+				os.Setenv(apiKeyEnv, value)
+			}
+			continue
+		}
+
+		if flags.Lookup(key) == nil || setFlags[key] {
+			continue
+		}
+
+		err = flags.Set(key, value)
+		if err != nil {
+			return fmt.Errorf("Error: Could not set --%s from the profile: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+func maskSecret(key string, value string) string {
+	for _, secret := range profileSecrets {
+		if key == secret && value != "" {
+			return maskedValue
+		}
+	}
+
+	return value
+}
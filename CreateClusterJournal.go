@@ -0,0 +1,427 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	createClusterJournalFilename = ".powervc-tool-journal.json"
+	createClusterJournalVersion  = 1
+
+	// The checksum of an input which does not exist
+	missingChecksum = "missing"
+)
+
+// createClusterPhase is one step of create-cluster.  Inputs are the files and directories, relative
+// to --directory, which the phase reads or rewrites.  Consumes are the inputs which the phase
+// hands to openshift-install, which either deletes them or bakes them into its own state.  After
+// that, rewriting them has no effect, so the phases which do are refused.  Run gets the journal
+// entry of the phase, to record what a later phase needs to know.  Plan, when a phase has one,
+// returns the files which the phase rewrites without writing them, for --dryRun.
+type createClusterPhase struct {
+	Number   int
+	Run      func(string, *journalPhase) error
+	Plan     func(string) ([]manifestEdit, error)
+	Inputs   []string
+	Consumes []string
+}
+
+var createClusterPhases = []createClusterPhase{
+	{Number: 1, Run: createClusterPhase1},
+	{Number: 2, Run: createClusterPhase2, Plan: planCreateClusterPhase2, Inputs: []string{"install-config.yaml"}},
+	{Number: 3, Run: createClusterPhase3, Inputs: []string{"install-config.yaml"}, Consumes: []string{"install-config.yaml"}},
+	{Number: 4, Run: createClusterPhase4, Inputs: []string{"metadata.json", "bootstrap.ign"}},
+	{Number: 5, Run: createClusterPhase5, Inputs: []string{"openshift", "cluster-api/machines"}},
+	{Number: 6, Run: createClusterPhase6, Inputs: []string{"metadata.json"}},
+	{Number: 7, Run: createClusterPhase7, Plan: planCreateClusterPhase7, Inputs: []string{"metadata.json", "manifests", "openshift", "cluster-api/machines"}},
+	// {Number: 8, Run: createClusterPhase8, Inputs: []string{"manifests", "openshift", "cluster-api"}, Consumes: []string{"manifests", "openshift", "cluster-api"}},
+}
+
+// journalPhase is a phase which completed.  The checksums are keyed by file, relative to
+// --directory, and are taken right before and right after the phase ran.  Image is the RHCOS
+// image which phase 6 made sure Glance has, and which phase 7 points the manifests at.
+type journalPhase struct {
+	Phase     int               `json:"phase"`
+	Started   time.Time         `json:"started"`
+	Completed time.Time         `json:"completed"`
+	Inputs    map[string]string `json:"inputs,omitempty"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+	Consumed  []string          `json:"consumed,omitempty"`
+	Image     string            `json:"image,omitempty"`
+}
+
+// journalConsumed is when an input was given to openshift-install.
+type journalConsumed struct {
+	Phase     int       `json:"phase"`
+	Completed time.Time `json:"completed"`
+}
+
+// createClusterJournal records which phases of create-cluster completed in a directory.  Consumed
+// is keyed by input and outlives the phases which consumed the inputs, since openshift-install
+// already has them even when an earlier phase is run again.
+type createClusterJournal struct {
+	Version  int                        `json:"version"`
+	Phases   []journalPhase             `json:"phases"`
+	Consumed map[string]journalConsumed `json:"consumed,omitempty"`
+}
+
+// loadCreateClusterJournal returns an empty journal if the directory has none yet.
+func loadCreateClusterJournal(directory string) (*createClusterJournal, error) {
+	var (
+		filename = filepath.Join(directory, createClusterJournalFilename)
+		content  []byte
+		journal  createClusterJournal
+		err      error
+	)
+
+	content, err = os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Debugf("loadCreateClusterJournal: %s does not exist", filename)
+		return &createClusterJournal{Version: createClusterJournalVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not read %s: %v", filename, err)
+	}
+
+	err = json.Unmarshal(content, &journal)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse %s: %v", filename, err)
+	}
+	if journal.Version != createClusterJournalVersion {
+		return nil, fmt.Errorf("Error: %s has version %d, expecting %d", filename, journal.Version, createClusterJournalVersion)
+	}
+	log.Debugf("loadCreateClusterJournal: %s has %d completed phases", filename, len(journal.Phases))
+
+	return &journal, nil
+}
+
+// save replaces the journal through a rename so a crash never leaves half of one.
+func (journal *createClusterJournal) save(directory string) error {
+	var (
+		filename = filepath.Join(directory, createClusterJournalFilename)
+		content  []byte
+		file     *os.File
+		err      error
+	)
+
+	content, err = json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err = os.CreateTemp(directory, createClusterJournalFilename+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error: Could not write %s: %v", file.Name(), err)
+	}
+
+	return os.Rename(file.Name(), filename)
+}
+
+// lastCompleted is the highest phase which completed, or 0.
+func (journal *createClusterJournal) lastCompleted() int {
+	var (
+		last = 0
+	)
+
+	for _, entry := range journal.Phases {
+		last = max(last, entry.Phase)
+	}
+	return last
+}
+
+// record adds a completed phase.  The entries of the phase and of every later phase are
+// dropped, since running a phase again overwrites what the later phases changed.  What they
+// consumed stays consumed.
+func (journal *createClusterJournal) record(entry journalPhase) {
+	journal.consume(entry)
+	journal.Phases = slices.DeleteFunc(journal.Phases, func(old journalPhase) bool {
+		return old.Phase >= entry.Phase
+	})
+	journal.Phases = append(journal.Phases, entry)
+	sort.Slice(journal.Phases, func(i, j int) bool {
+		return journal.Phases[i].Phase < journal.Phases[j].Phase
+	})
+}
+
+// consume marks the inputs which a completed phase consumed.  The first phase to consume an
+// input is the one which is kept.
+func (journal *createClusterJournal) consume(entry journalPhase) {
+	for _, input := range entry.Consumed {
+		if journal.Consumed == nil {
+			journal.Consumed = make(map[string]journalConsumed)
+		}
+		if _, ok := journal.Consumed[input]; ok {
+			continue
+		}
+
+		journal.Consumed[input] = journalConsumed{
+			Phase:     entry.Phase,
+			Completed: entry.Completed,
+		}
+	}
+}
+
+// rhcosImage is the image which the last run of phase 6 recorded.
+func (journal *createClusterJournal) rhcosImage() (string, error) {
+	for _, entry := range journal.Phases {
+		if entry.Phase == 6 && entry.Image != "" {
+			return entry.Image, nil
+		}
+	}
+
+	return "", fmt.Errorf("Error: Phase 6 has not recorded the RHCOS image, please run it first")
+}
+
+// checkRunnable refuses a phase when one of its inputs was already consumed by openshift-install.
+func (journal *createClusterJournal) checkRunnable(phase createClusterPhase) error {
+	for _, input := range phase.Inputs {
+		consumed, ok := journal.Consumed[input]
+		if !ok {
+			continue
+		}
+
+		return fmt.Errorf("Error: Phase %d uses %s, which phase %d already gave to openshift-install on %s.  Start over with a new directory",
+			phase.Number,
+			input,
+			consumed.Phase,
+			consumed.Completed.Format(time.RFC3339),
+		)
+	}
+
+	return nil
+}
+
+// changedInputs lists the inputs of a phase which are different from when an earlier phase left
+// them, for example because they were edited by hand in between.
+func (journal *createClusterJournal) changedInputs(phase createClusterPhase, checksums map[string]string) []string {
+	var (
+		changed []string
+	)
+
+	for file, checksum := range checksums {
+		for i := len(journal.Phases) - 1; i >= 0; i-- {
+			entry := journal.Phases[i]
+			if entry.Phase >= phase.Number {
+				continue
+			}
+
+			recorded, ok := entry.Outputs[file]
+			if !ok {
+				continue
+			}
+			if recorded != checksum {
+				changed = append(changed, fmt.Sprintf("%s changed since phase %d completed", file, entry.Phase))
+			}
+			break
+		}
+	}
+	sort.Strings(changed)
+
+	return changed
+}
+
+// runCreateClusterPhase runs one phase and records it in the journal.
+func runCreateClusterPhase(directory string, journal *createClusterJournal, phase createClusterPhase) error {
+	var (
+		entry = journalPhase{
+			Phase:    phase.Number,
+			Started:  time.Now(),
+			Consumed: phase.Consumes,
+		}
+		err error
+	)
+
+	err = journal.checkRunnable(phase)
+	if err != nil {
+		return err
+	}
+
+	entry.Inputs, err = checksumInputs(directory, phase.Inputs)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range journal.changedInputs(phase, entry.Inputs) {
+		fmt.Printf("Warning: %s\n", file)
+	}
+
+	fmt.Printf("Running phase %d\n", phase.Number)
+
+	err = phase.Run(directory, &entry)
+	if err != nil {
+		return fmt.Errorf("Error: Phase %d failed: %v", phase.Number, err)
+	}
+
+	entry.Outputs, err = checksumInputs(directory, phase.Inputs)
+	if err != nil {
+		return err
+	}
+	entry.Completed = time.Now()
+
+	journal.record(entry)
+
+	return journal.save(directory)
+}
+
+// dryRunCreateClusterPhase prints a unified diff of every file which the phase would rewrite.
+// The files are the ones in the directory now, so a phase whose inputs an earlier phase has not
+// created yet is skipped.
+func dryRunCreateClusterPhase(directory string, phase createClusterPhase) error {
+	var (
+		checksums map[string]string
+		edits     []manifestEdit
+		err       error
+	)
+
+	if phase.Plan == nil {
+		fmt.Printf("Phase %d: not run, it has no dry run\n", phase.Number)
+		return nil
+	}
+
+	checksums, err = checksumInputs(directory, phase.Inputs)
+	if err != nil {
+		return err
+	}
+	for _, input := range phase.Inputs {
+		if checksums[input] == missingChecksum {
+			fmt.Printf("Phase %d: skipped, %s does not exist yet\n", phase.Number, input)
+			return nil
+		}
+	}
+
+	edits, err = phase.Plan(directory)
+	if err != nil {
+		return fmt.Errorf("Error: Phase %d failed: %v", phase.Number, err)
+	}
+
+	if len(edits) == 0 {
+		fmt.Printf("Phase %d: no changes\n", phase.Number)
+		return nil
+	}
+
+	for _, edit := range edits {
+		relative, err := filepath.Rel(directory, edit.Filename)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Phase %d: %s: %s\n", phase.Number, relative, strings.Join(edit.Rules, ", "))
+		fmt.Print(unifiedDiff("a/"+relative, "b/"+relative, edit.Old, edit.New))
+	}
+
+	return nil
+}
+
+// checksumInputs returns the SHA-256 of every file in inputs.  A directory is replaced by the
+// files under it.
+func checksumInputs(directory string, inputs []string) (map[string]string, error) {
+	var (
+		checksums = make(map[string]string)
+		err       error
+	)
+
+	for _, input := range inputs {
+		var (
+			path = filepath.Join(directory, input)
+			info os.FileInfo
+		)
+
+		info, err = os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			checksums[input] = missingChecksum
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			checksums[input], err = checksumFile(path)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			relative, err := filepath.Rel(directory, file)
+			if err != nil {
+				return err
+			}
+
+			checksums[relative], err = checksumFile(file)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return checksums, nil
+}
+
+func checksumFile(filename string) (string, error) {
+	var (
+		file   *os.File
+		hasher = sha256.New()
+		err    error
+	)
+
+	file, err = os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(hasher, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
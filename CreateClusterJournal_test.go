@@ -0,0 +1,99 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateClusterJournalConsumed(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		completed = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		phase3    = createClusterPhase{Number: 3, Inputs: []string{"install-config.yaml"}, Consumes: []string{"install-config.yaml"}}
+		journal   = &createClusterJournal{Version: createClusterJournalVersion}
+	)
+
+	journal.record(journalPhase{Phase: 2, Completed: completed})
+	journal.record(journalPhase{Phase: 3, Completed: completed, Consumed: phase3.Consumes})
+	if err := journal.checkRunnable(phase3); err == nil {
+		t.Fatalf("phase 3 is runnable after it consumed install-config.yaml")
+	}
+
+	// Running phase 2 again drops the entry of phase 3, but openshift-install still has the
+	// install-config.
+	journal.record(journalPhase{Phase: 2, Completed: completed.Add(time.Hour)})
+	if journal.lastCompleted() != 2 {
+		t.Errorf("lastCompleted is %d, want 2", journal.lastCompleted())
+	}
+	if err := journal.checkRunnable(phase3); err == nil {
+		t.Errorf("phase 3 is runnable after phase 2 was run again")
+	}
+
+	if err := journal.save(directory); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadCreateClusterJournal(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed := loaded.Consumed["install-config.yaml"]; consumed.Phase != 3 || !consumed.Completed.Equal(completed) {
+		t.Errorf("loaded journal has install-config.yaml consumed as %+v", consumed)
+	}
+	if err := loaded.checkRunnable(createClusterPhase{Number: 4, Inputs: []string{"metadata.json"}}); err != nil {
+		t.Errorf("phase 4 is not runnable: %v", err)
+	}
+}
+
+func TestCreateClusterJournalRHCOSImage(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		journal   = &createClusterJournal{Version: createClusterJournalVersion}
+		phase6    = createClusterPhase{
+			Number: 6,
+			Run: func(directory string, entry *journalPhase) error {
+				entry.Image = "rhcos-9.6.20250601-0-openstack.ppc64le"
+				return nil
+			},
+		}
+	)
+
+	if _, err := journal.rhcosImage(); err == nil {
+		t.Errorf("rhcosImage succeeded before phase 6 ran")
+	}
+
+	if err := runCreateClusterPhase(directory, journal, phase6); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadCreateClusterJournal(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := loaded.rhcosImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "rhcos-9.6.20250601-0-openstack.ppc64le" {
+		t.Errorf("rhcosImage is %q", image)
+	}
+
+	// Running phase 5 again drops phase 6, and with it the image.
+	loaded.record(journalPhase{Phase: 5, Completed: time.Now()})
+	if _, err := loaded.rhcosImage(); err == nil {
+		t.Errorf("rhcosImage succeeded after phase 5 was run again")
+	}
+}
@@ -17,7 +17,7 @@ package main
 //
 // Make sure the IPI installer can run.
 //
-func createClusterPhase1(directory string) error {
+func createClusterPhase1(directory string, _ *journalPhase) error {
 	var (
 		err error
 	)
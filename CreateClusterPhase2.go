@@ -15,10 +15,10 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
 
 	"sigs.k8s.io/yaml"
 )
@@ -37,17 +37,19 @@ func replacePlatformArray(node []interface{}) error {
 }
 
 // process an unmarshalled JSON map structure by finding every platform element, and replacing the powervc
-// child element with an openstack element.
+// child element with an openstack element.  A platform which already is openstack is left alone, so
+// that the phase can be run again.
 func replacePlatformMap(node map[string]any) error {
 	for k, v := range node {
 		switch value := v.(type) {
 		case map[string]any:
 			if k == "platform" {
 				nodePowerVC, ok := value["powervc"]
+				_, isOpenStack := value["openstack"]
 				if ok {
 					value["openstack"] = nodePowerVC
 					delete(value, "powervc")
-				} else {
+				} else if !isOpenStack {
 					return fmt.Errorf("could not convert powervc in the json")
 				}
 
@@ -70,11 +72,41 @@ func replacePlatformMap(node map[string]any) error {
 //
 // Replace powervc platform with openstack platform
 //
-func createClusterPhase2(directory string) error {
+func createClusterPhase2(directory string, _ *journalPhase) error {
+	var (
+		edits []manifestEdit
+		err   error
+	)
+
+	edits, err = planCreateClusterPhase2(directory)
+	if err != nil {
+		return err
+	}
+
+	err = writeManifestEdits(edits)
+	if err != nil {
+		return err
+	}
+
+if false {
+	err = runSplitCommand([]string{
+		"sed",
+		"-i",
+		"s,subnet: null,subnet:,",
+		fmt.Sprintf("%s/%s", directory, "install-config.yaml"),
+	})
+}
+
+	return nil
+}
+
+// planCreateClusterPhase2 works out the openstack install-config.yaml without writing it.
+func planCreateClusterPhase2(directory string) ([]manifestEdit, error) {
 	var (
 		abyteYamlOld []byte
 		abyteJsonOld []byte
 		jsonOld      map[string]any
+		abyteJsonRaw []byte
 		abyteJsonNew []byte
 		abyteYamlNew []byte
 		err          error
@@ -82,49 +114,53 @@ func createClusterPhase2(directory string) error {
 
 	abyteYamlOld, err = ioutil.ReadFile(fmt.Sprintf("%s/%s", directory, "install-config.yaml"))
 	if err != nil {
-		return fmt.Errorf("Error reading YAML file: %v", err)
+		return nil, fmt.Errorf("Error reading YAML file: %v", err)
 	}
 
 	abyteJsonOld, err = yaml.YAMLToJSON(abyteYamlOld)
 	if err != nil {
-		return fmt.Errorf("Error: could not convert yaml to json: %v", err)
+		return nil, fmt.Errorf("Error: could not convert yaml to json: %v", err)
 	}
 	log.Debugf("abyteJsonOld = %+v", string(abyteJsonOld))
 
 	err = json.Unmarshal(abyteJsonOld, &jsonOld)
 	if err != nil {
-		return fmt.Errorf("Error: could not unmarshal the json: %v", err)
+		return nil, fmt.Errorf("Error: could not unmarshal the json: %v", err)
+	}
+
+	// Marshalled the same way as after the replacement, so the two can be compared.
+	abyteJsonRaw, err = json.Marshal(jsonOld)
+	if err != nil {
+		return nil, err
 	}
 
 	err = replacePlatformMap(jsonOld)
 	if err != nil {
-		return fmt.Errorf("Error: could not replacePlatformMap the json: %v", err)
+		return nil, fmt.Errorf("Error: could not replacePlatformMap the json: %v", err)
 	}
 	log.Debugf("jsonOld = %+v", jsonOld)
 
 	abyteJsonNew, err = json.Marshal(jsonOld)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	abyteYamlNew, err = yaml.JSONToYAML(abyteJsonNew)
-	if err != nil {
-		return err
+	if bytes.Equal(abyteJsonRaw, abyteJsonNew) {
+		log.Debugf("planCreateClusterPhase2: the platform already is openstack")
+		return nil, nil
 	}
 
-	err = os.WriteFile(fmt.Sprintf("%s/%s", directory, "install-config.yaml"), abyteYamlNew, 0644)
+	abyteYamlNew, err = yaml.JSONToYAML(abyteJsonNew)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-if false {
-	err = runSplitCommand([]string{
-		"sed",
-		"-i",
-		"s,subnet: null,subnet:,",
-		fmt.Sprintf("%s/%s", directory, "install-config.yaml"),
-	})
-}
-
-	return nil
+	return []manifestEdit{
+		{
+			Filename: fmt.Sprintf("%s/%s", directory, "install-config.yaml"),
+			Old:      abyteYamlOld,
+			New:      abyteYamlNew,
+			Rules:    []string{"replacePlatformMap"},
+		},
+	}, nil
 }
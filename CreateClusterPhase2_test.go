@@ -0,0 +1,85 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testInstallConfig = `apiVersion: v1
+baseDomain: example.com
+metadata:
+  name: test
+platform:
+  powervc:
+    cloud: powervc
+    clusterOSImage: rhcos
+`
+
+func TestPlanCreateClusterPhase2(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		filename  = filepath.Join(directory, "install-config.yaml")
+	)
+
+	err := os.WriteFile(filename, []byte(testInstallConfig), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits, err := planCreateClusterPhase2(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("got %d edits, want 1", len(edits))
+	}
+
+	node, err := yamlToMap(edits[0].New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloud := lookup(node, "platform", "openstack", "cloud"); cloud != "powervc" {
+		t.Errorf("platform.openstack.cloud is %v", cloud)
+	}
+	if powervc := lookup(node, "platform", "powervc"); powervc != nil {
+		t.Errorf("platform.powervc is still there: %v", powervc)
+	}
+
+	// Once the platform is openstack, running the phase again changes nothing.
+	err = writeManifestEdits(edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits, err = planCreateClusterPhase2(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("got %d edits for an openstack install-config", len(edits))
+	}
+
+	// Neither powervc nor openstack is still an error.
+	err = os.WriteFile(filename, []byte("platform:\n  none: {}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = planCreateClusterPhase2(directory)
+	if err == nil {
+		t.Errorf("planCreateClusterPhase2 succeeded without a powervc platform")
+	}
+}
@@ -21,7 +21,7 @@ import (
 // Process the install config.
 // Create the ignition config.
 //
-func createClusterPhase3(directory string) error {
+func createClusterPhase3(directory string, _ *journalPhase) error {
 	var (
 		err error
 	)
@@ -66,7 +66,7 @@ func NewServiceClient(ctx context.Context, service string, opts *clientconfig.Cl
 //
 // Upload the bootstrap igniton file to Swift.
 //
-func createClusterPhase4(directory string) error {
+func createClusterPhase4(directory string, _ *journalPhase) error {
 	var (
 		metadata      *Metadata
 		cloud         string
@@ -15,180 +15,63 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-
-	"sigs.k8s.io/yaml"
+	"time"
 )
 
-const (
-//	rhcosImage = "hamzy2-419-96-20250402-0-ppc64le"
-	rhcosImage = "hamzy3-rhcos-9.6.20250826-1-openstack-ppc64le"
+var (
+	// Set by create-cluster.  An empty rhcosStreamFilename asks openshift-install for the stream,
+	// and an empty rhcosImageName uses the name of the stream's artifact.
+	rhcosStreamFilename string
+	rhcosImageFilename  string
+	rhcosImageName      string
 )
 
 //
-// Change the image used by the VMs.
+// Make sure Glance has the image used by the VMs.  The image is recorded in the journal, and
+// phase 7 points the manifests at it.
 //
-func createClusterPhase6(directory string) error {
-	var (
-		err           error
-	)
-
-	err = processManifestsImage(directory)
-
-	return err
-}
-
-func processManifestsImage(directory string) error {
+func createClusterPhase6(directory string, entry *journalPhase) error {
 	var (
-		err error
+		metadata  *Metadata
+		stream    *coreosStream
+		artifact  rhcosArtifact
+		imageName string
+		ctx       context.Context
+		cancel    context.CancelFunc
+		err       error
 	)
 
-	err = processManifestDirectoryImage(fmt.Sprintf("%s/%s", directory, "openshift"))
+	metadata, err = NewMetadataFromCCMetadata(fmt.Sprintf("%s/%s", directory, "metadata.json"))
 	if err != nil {
 		return err
 	}
 
-	err = processManifestDirectoryImage(fmt.Sprintf("%s/%s", directory, "cluster-api/machines"))
-
-	return err
-}
-
-func processManifestDirectoryImage(directory string) error {
-	var (
-		entries  []os.DirEntry
-		entry    os.DirEntry
-		filename string
-		err      error
-	)
-
-	log.Debugf("processManifestDirectoryImage: %s", directory)
-
-	entries, err = os.ReadDir(directory)
+	stream, err = readCoreOSStream(rhcosStreamFilename)
 	if err != nil {
 		return err
 	}
 
-	for _, entry = range entries {
-		filename = fmt.Sprintf("%s/%s", directory, entry.Name())
-		log.Debugf("processManifestDirectoryImage: %s", filename)
-
-		err = changeImagesManifest(filename)
-		if err != nil {
-			return err
-		}
-	}
-
-	return err
-}
-
-func changeImagesManifest(filename string) error {
-	var (
-		abyteYamlOld []byte
-		abyteJsonOld []byte
-		jsonOld      map[string]any
-		changed      = false
-		abyteJsonNew []byte
-		abyteYamlNew []byte
-		err          error
-	)
-
-	abyteYamlOld, err = ioutil.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("Error reading YAML file: %v", err)
-	}
-
-	abyteJsonOld, err = yaml.YAMLToJSON(abyteYamlOld)
-	if err != nil {
-		return fmt.Errorf("Error: could not convert yaml to json: %v", err)
-	}
-//	log.Debugf("abyteJsonOld = %+v", string(abyteJsonOld))
-
-	err = json.Unmarshal(abyteJsonOld, &jsonOld)
-	if err != nil {
-		return fmt.Errorf("Error: could not unmarshal the json: %v", err)
-	}
-	log.Debugf("jsonOld = %+v", jsonOld)
-
-	err = changeImages(jsonOld)
-	log.Debugf("jsonOld = %+v", jsonOld)
-	if err != nil {
-		if err.Error() != "CHANGED-IMAGE" {
-			return err
-		}
-		log.Debugf("Found CHANGED-IMAGE")
-		changed = true
-	}
-
-	if !changed {
-		return nil
-	}
-
-	abyteJsonNew, err = json.Marshal(jsonOld)
+	artifact, err = findRHCOSArtifact(stream)
 	if err != nil {
 		return err
 	}
-
-	abyteYamlNew, err = yaml.JSONToYAML(abyteJsonNew)
-	if err != nil {
-		return err
+	if rhcosImageName != "" {
+		artifact.Name = rhcosImageName
 	}
+	log.Debugf("createClusterPhase6: artifact = %+v", artifact)
+
+	ctx, cancel = context.WithTimeout(context.TODO(), 60*time.Minute)
+	defer cancel()
 
-	err = os.WriteFile(filename, abyteYamlNew, 0644)
+	imageName, err = ensureRHCOSImage(ctx, metadata.GetCloud(), artifact, rhcosImageFilename)
 	if err != nil {
 		return err
 	}
+	fmt.Printf("Using image %s\n", imageName)
 
-	return err
-}
-
-func changeImages(node map[string]any) error {
-	for k, v := range node {
-		if k == "image" {
-			log.Debugf("FOUND image! type: %T", v)
-			_, ok := node[k].(map[string]any)
-			log.Debugf("ok = %v", ok)
-			if ok {
-				delete(node, "image")
-				node["image"] = map[string]any {
-					"filter":  map[string]string{
-						"name": rhcosImage,
-					},
-				}
-				return fmt.Errorf("CHANGED-IMAGE")
-			} else {
-				node[k] = rhcosImage
-				return fmt.Errorf("CHANGED-IMAGE")
-			}
-		}
-		switch value := v.(type) {
-		case map[string]any:
-			log.Debugf("map[string]any: key = %s, value = %+v", k, value)
-
-			if err := changeImages(value); err != nil {
-				return err
-			}
-		case []interface{}:
-			log.Debugf("[]interface{}: key = %s, value = %+v", k, value)
-			if err := changeImagesArray(value); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-func changeImagesArray(node []interface{}) error {
-	for _, value := range node {
-		if mapChild, ok := value.(map[string]any); ok {
-			if err := changeImages(mapChild); err != nil {
-				return err
-			}
-		}
-	}
+	entry.Image = imageName
 
 	return nil
 }
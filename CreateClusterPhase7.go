@@ -15,128 +15,65 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"strings"
-
-	"sigs.k8s.io/yaml"
 )
 
 //
-// Change the LoadBalancer enabled to false in the cloud provider config.
+// Change the manifests to work on PowerVC, such as removing the security groups, using the RHCOS
+// image, and disabling the LoadBalancer in the cloud provider config.
 //
-func createClusterPhase7(directory string) error {
+func createClusterPhase7(directory string, _ *journalPhase) error {
 	var (
-		filename = "test/manifests/cloud-provider-config.yaml"
-		err      error
+		edits []manifestEdit
+		err   error
 	)
 
 	fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
 
-	err = processCloudProviderConfig(filename)
+	edits, err = planCreateClusterPhase7(directory)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return writeManifestEdits(edits)
 }
 
-func processCloudProviderConfig(filename string) error {
+// planCreateClusterPhase7 works out what the rules change in the manifests without writing them.
+func planCreateClusterPhase7(directory string) ([]manifestEdit, error) {
 	var (
-		abyteYamlOld []byte
-		abyteJsonOld []byte
-		jsonOld      map[string]any
-		changed      = false
-		abyteJsonNew []byte
-		abyteYamlNew []byte
-		err          error
+		metadata  *Metadata
+		rules     *manifestRules
+		journal   *createClusterJournal
+		imageName string
+		err       error
 	)
 
-	abyteYamlOld, err = ioutil.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("Error reading YAML file: %v", err)
-	}
-
-	abyteJsonOld, err = yaml.YAMLToJSON(abyteYamlOld)
-	if err != nil {
-		return fmt.Errorf("Error: could not convert yaml to json: %v", err)
-	}
-//	log.Debugf("abyteJsonOld = %+v", string(abyteJsonOld))
-
-	err = json.Unmarshal(abyteJsonOld, &jsonOld)
+	metadata, err = NewMetadataFromCCMetadata(fmt.Sprintf("%s/%s", directory, "metadata.json"))
 	if err != nil {
-		return fmt.Errorf("Error: could not unmarshal the json: %v", err)
+		return nil, err
 	}
-//	log.Debugf("jsonOld = %+v", jsonOld)
 
-	err = changeCloudProviderConfig(jsonOld)
-//	log.Debugf("jsonOld = %+v", jsonOld)
+	rules, err = loadManifestRules(manifestRulesFilename)
 	if err != nil {
-		if err.Error() != "ADDED-LOADBALANCER" {
-			return err
-		}
-		log.Debugf("Found ADDED-LOADBALANCER")
-		changed = true
-	}
-
-	if !changed {
-		return nil
+		return nil, err
 	}
 
-	abyteJsonNew, err = json.Marshal(jsonOld)
+	// The image phase 6 made sure of, not what the stream or --rhcosImageName say now.
+	journal, err = loadCreateClusterJournal(directory)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	abyteYamlNew, err = yaml.JSONToYAML(abyteJsonNew)
-	if err != nil {
-		return err
-	}
-
-	err = os.WriteFile(filename, abyteYamlNew, 0644)
+	imageName, err = journal.rhcosImage()
 	if err != nil {
-		return err
-	}
-
-	return err
-}
-
-func changeCloudProviderConfig(jsonOld map[string]any) error {
-	var (
-		ok      bool
-		dataMap map[string]any
-		config  string
-	)
-
-//	log.Debugf("jsonOld = %+v", jsonOld)
-
-	v, ok := jsonOld["data"]
-	if !ok {
-		return fmt.Errorf("Could not find data in cloud provider config")
+		return nil, err
 	}
-
-	dataMap, ok = v.(map[string]any)
-	if !ok {
-		return fmt.Errorf("Could not convert data to map[string]any")
-	}
-
-	v, ok = dataMap["config"]
-	if !ok {
-		return fmt.Errorf("Could not find config in dataMap")
-	}
-
-	config, ok = v.(string)
-	if !ok {
-		return fmt.Errorf("Could not convert config to string")
+	if rhcosImageName != "" && rhcosImageName != imageName {
+		return nil, fmt.Errorf("Error: Phase 6 made sure of the image %s, not %s.  Please run phase 6 again.", imageName, rhcosImageName)
 	}
-	log.Debugf("config = %+v", config)
-
-	if strings.Contains(config, "[LoadBalancer]") {
-		return nil
-	}
-
-	config = config + "[LoadBalancer]\nenabled = false\n"
-
-	dataMap["config"] = config
 
-	return fmt.Errorf("ADDED-LOADBALANCER")
+	return planManifestRules(directory, rules, map[string]string{
+		"clusterName":    metadata.GetClusterName(),
+		"infraID":        metadata.GetInfraID(),
+		"rhcosImageName": imageName,
+	})
 }
@@ -20,7 +20,7 @@ import (
 //
 // Create the OpenShift cluster.
 //
-func createClusterPhase8(directory string) error {
+func createClusterPhase8(directory string, _ *journalPhase) error {
 	var (
 		err           error
 	)
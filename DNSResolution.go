@@ -0,0 +1,333 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	DNSResolutionName = "Domain Name Resolution"
+
+	// Used when --resolver is not set
+	resolvConfFilename = "/etc/resolv.conf"
+
+	// How long to wait for one answer
+	dnsQueryTimeout = 5 * time.Second
+
+	// A name which the default ingress controller always serves
+	sampleAppsHost = "console-openshift-console"
+)
+
+type DNSResolution struct {
+	services *Services
+}
+
+func NewDNSResolution(services *Services) ([]RunnableObject, []error) {
+	var (
+		drs  []*DNSResolution
+		errs []error
+		ros  []RunnableObject
+	)
+
+	drs, errs = innerNewDNSResolution(services)
+
+	ros = make([]RunnableObject, len(drs))
+	// Go does not support type converting the entire array.
+	// So we do it manually.
+	for i, v := range drs {
+		ros[i] = RunnableObject(v)
+	}
+
+	return ros, errs
+}
+
+func NewDNSResolutionAlt(services *Services) ([]*DNSResolution, []error) {
+	return innerNewDNSResolution(services)
+}
+
+func innerNewDNSResolution(services *Services) ([]*DNSResolution, []error) {
+	var (
+		drs  []*DNSResolution
+		errs []error
+	)
+
+	drs = make([]*DNSResolution, 1)
+	errs = make([]error, 1)
+
+	drs[0] = &DNSResolution{
+		services: services,
+	}
+
+	return drs, errs
+}
+
+func (dr *DNSResolution) Name() (string, error) {
+	return DNSResolutionName, nil
+}
+
+func (dr *DNSResolution) ObjectName() (string, error) {
+	return DNSResolutionName, nil
+}
+
+func (dr *DNSResolution) Run() error {
+	// Nothing needs to be done here.
+	return nil
+}
+
+// dnsAnswer is what a DNS server answered for the A records of a name.  CNAMEs are followed by
+// the server, so ipAddresses are the addresses at the end of the chain.
+type dnsAnswer struct {
+	rcode       int
+	ipAddresses []string
+	ttl         uint32
+}
+
+// expectedName is a name the cluster needs, and the address it should resolve to.
+type expectedName struct {
+	name      string
+	ipAddress string
+}
+
+// ClusterStatus resolves the names which watch-installation creates, in whichever DNS they live,
+// and compares the answers with the addresses OpenStack has for the bastion and the nodes.
+func (dr *DNSResolution) ClusterStatus(ctx context.Context) CheckResult {
+	var (
+		cancel           context.CancelFunc
+		cloud            string
+		clusterName      string
+		infraID          string
+		baseDomain       string
+		resolver         string
+		bastionServer    servers.Server
+		bastionIpAddress string
+		allServers       []servers.Server
+		expected         []expectedName
+		client           *dns.Client
+		failedQueries    = 0
+		findings         []Finding
+		err              error
+	)
+
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cloud = dr.services.GetCloud()
+	clusterName = dr.services.GetMetadata().GetClusterName()
+	infraID = dr.services.GetMetadata().GetInfraID()
+	baseDomain = dr.services.GetBaseDomain()
+	log.Debugf("ClusterStatus: clusterName = %s, baseDomain = %s", clusterName, baseDomain)
+
+	resolver, err = dnsResolverAddress(dr.services.GetResolver())
+	if err != nil {
+		return unknownCheckResult(DNSResolutionName, "Could not find a DNS server: %v", err)
+	}
+	log.Debugf("ClusterStatus: resolver = %s", resolver)
+
+	bastionServer, err = findServer(ctx, cloud, clusterName)
+	if err != nil {
+		return unknownCheckResult(DNSResolutionName, "findServer returns error %v", err)
+	}
+
+	_, bastionIpAddress, err = findIpAddress(bastionServer)
+	if err != nil || bastionIpAddress == "" {
+		return unknownCheckResult(DNSResolutionName, "%s has no IP address (%v)", bastionServer.Name, err)
+	}
+
+	allServers, err = getAllServers(ctx, cloud)
+	if err != nil {
+		return unknownCheckResult(DNSResolutionName, "getAllServers returns error %v", err)
+	}
+
+	expected = expectedClusterNames(clusterName, infraID, baseDomain, bastionIpAddress, allServers)
+
+	client = &dns.Client{
+		Timeout: dnsQueryTimeout,
+	}
+
+	for _, e := range expected {
+		var (
+			answer  dnsAnswer
+			finding Finding
+		)
+
+		answer, err = resolveA(ctx, client, resolver, e.name)
+		if err != nil {
+			failedQueries++
+			finding = newFinding(e.name, SeverityError, "Resolving %s with %s returns error %v", e.name, resolver, err)
+			finding.Fields = map[string]string{"resolves": "error"}
+			findings = append(findings, finding)
+			continue
+		}
+
+		findings = append(findings, compareAnswer(e, answer))
+	}
+
+	if failedQueries == len(expected) {
+		return unknownCheckResult(DNSResolutionName, "No answers from %s: %v", resolver, err)
+	}
+
+	return newCheckResult(findings)
+}
+
+// expectedClusterNames lists the names watch-installation creates: api, api-int and *.apps point
+// at the bastion, and every bootstrap, master and worker has a record of its own.
+func expectedClusterNames(clusterName string, infraID string, baseDomain string, bastionIpAddress string, allServers []servers.Server) []expectedName {
+	var (
+		expected []expectedName
+		nodes    []expectedName
+	)
+
+	expected = []expectedName{
+		{name: fmt.Sprintf("api.%s.%s", clusterName, baseDomain), ipAddress: bastionIpAddress},
+		{name: fmt.Sprintf("api-int.%s.%s", clusterName, baseDomain), ipAddress: bastionIpAddress},
+		{name: fmt.Sprintf("%s.apps.%s.%s", sampleAppsHost, clusterName, baseDomain), ipAddress: bastionIpAddress},
+	}
+
+	for _, server := range allServers {
+		if !strings.HasPrefix(strings.ToLower(server.Name), infraID) {
+			continue
+		}
+		if !slices.ContainsFunc(
+			[]string{"bootstrap", "master", "worker"},
+			func(s string) bool {
+				return strings.Contains(server.Name, s)
+			}) {
+			continue
+		}
+
+		_, ipAddress, err := findIpAddress(server)
+		if err != nil || ipAddress == "" {
+			log.Debugf("expectedClusterNames: SKIPPING server = %s, no IP address", server.Name)
+			continue
+		}
+
+		nodes = append(nodes, expectedName{name: fmt.Sprintf("%s.%s", server.Name, baseDomain), ipAddress: ipAddress})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].name < nodes[j].name
+	})
+
+	return append(expected, nodes...)
+}
+
+// compareAnswer turns an answer into a finding.  The TTL is only in the message since it counts
+// down between two checks.
+func compareAnswer(e expectedName, answer dnsAnswer) Finding {
+	var (
+		finding Finding
+	)
+
+	switch {
+	case answer.rcode != dns.RcodeSuccess:
+		finding = newFinding(e.name, SeverityError, "%s does not resolve (%s), expecting %s", e.name, dns.RcodeToString[answer.rcode], e.ipAddress)
+		finding.Fields = map[string]string{"resolves": "missing"}
+	case len(answer.ipAddresses) == 0:
+		finding = newFinding(e.name, SeverityError, "%s has no A record, expecting %s", e.name, e.ipAddress)
+		finding.Fields = map[string]string{"resolves": "missing"}
+	case !slices.Contains(answer.ipAddresses, e.ipAddress):
+		finding = newFinding(e.name, SeverityError, "%s resolves to %s with TTL %d, expecting %s", e.name, strings.Join(answer.ipAddresses, ", "), answer.ttl, e.ipAddress)
+		finding.Fields = map[string]string{"resolves": "mismatch"}
+	case len(answer.ipAddresses) > 1:
+		finding = newFinding(e.name, SeverityWarning, "%s resolves to %s with TTL %d, only expecting %s", e.name, strings.Join(answer.ipAddresses, ", "), answer.ttl, e.ipAddress)
+		finding.Fields = map[string]string{"resolves": "extra"}
+	default:
+		finding = newFinding(e.name, SeverityInfo, "%s resolves to %s with TTL %d", e.name, e.ipAddress, answer.ttl)
+		finding.Fields = map[string]string{"resolves": "match"}
+	}
+
+	return finding
+}
+
+// resolveA asks server for the A records of name.  The TTL is the lowest TTL in the answer, which
+// is how long the whole answer can be cached.
+func resolveA(ctx context.Context, client *dns.Client, server string, name string) (dnsAnswer, error) {
+	var (
+		query    = new(dns.Msg)
+		response *dns.Msg
+		answer   dnsAnswer
+		first    = true
+		err      error
+	)
+
+	query.SetQuestion(dns.Fqdn(name), dns.TypeA)
+
+	response, _, err = client.ExchangeContext(ctx, query, server)
+	if err != nil {
+		return answer, err
+	}
+	log.Debugf("resolveA: %s: rcode = %s, answers = %d", name, dns.RcodeToString[response.Rcode], len(response.Answer))
+
+	answer.rcode = response.Rcode
+
+	for _, rr := range response.Answer {
+		if first || rr.Header().Ttl < answer.ttl {
+			answer.ttl = rr.Header().Ttl
+			first = false
+		}
+
+		if a, ok := rr.(*dns.A); ok {
+			answer.ipAddresses = append(answer.ipAddresses, a.A.String())
+		}
+	}
+	sort.Strings(answer.ipAddresses)
+
+	return answer, nil
+}
+
+// dnsResolverAddress returns the host:port of the DNS server to ask.  Without a resolver, this is
+// the first nameserver in /etc/resolv.conf.
+func dnsResolverAddress(resolver string) (string, error) {
+	var (
+		config *dns.ClientConfig
+		err    error
+	)
+
+	if resolver != "" {
+		if _, _, err = net.SplitHostPort(resolver); err != nil {
+			return net.JoinHostPort(resolver, "53"), nil
+		}
+		return resolver, nil
+	}
+
+	config, err = dns.ClientConfigFromFile(resolvConfFilename)
+	if err != nil {
+		return "", err
+	}
+	if len(config.Servers) == 0 {
+		return "", fmt.Errorf("Error: %s has no nameservers", resolvConfFilename)
+	}
+
+	return net.JoinHostPort(config.Servers[0], config.Port), nil
+}
+
+func (dr *DNSResolution) Priority() (int, error) {
+	return -1, nil
+}
+
+func (dr *DNSResolution) Dependencies() []string {
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// The lines around a change, the same as diff -u
+	diffContext = 3
+)
+
+// diffLine is one line of a diff.  Kind is ' ', '-' or '+'.
+type diffLine struct {
+	Kind byte
+	Text string
+}
+
+// unifiedDiff returns the changes from oldContent to newContent in the unified format, or "" when
+// they are the same.
+func unifiedDiff(oldName string, newName string, oldContent []byte, newContent []byte) string {
+	var (
+		oldLines = splitLines(oldContent)
+		newLines = splitLines(newContent)
+		lines    []diffLine
+		builder  strings.Builder
+	)
+
+	lines = diffLines(oldLines, newLines)
+
+	fmt.Fprintf(&builder, "--- %s\n", oldName)
+	fmt.Fprintf(&builder, "+++ %s\n", newName)
+
+	changed := false
+	for start := 0; start < len(lines); {
+		var (
+			end              int
+			oldStart, oldLen int
+			newStart, newLen int
+		)
+
+		// Find the next change.
+		for start < len(lines) && lines[start].Kind == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		changed = true
+
+		// A hunk keeps going while the changes are closer than twice the context.
+		end = start
+		for i := start; i < len(lines); i++ {
+			if lines[i].Kind != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*diffContext {
+				break
+			}
+		}
+
+		start = max(0, start-diffContext)
+		end = min(len(lines), end+diffContext)
+
+		// Count the lines of both files before the hunk and in it.
+		for _, line := range lines[:start] {
+			if line.Kind != '+' {
+				oldStart++
+			}
+			if line.Kind != '-' {
+				newStart++
+			}
+		}
+		for _, line := range lines[start:end] {
+			if line.Kind != '+' {
+				oldLen++
+			}
+			if line.Kind != '-' {
+				newLen++
+			}
+		}
+
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+		for _, line := range lines[start:end] {
+			fmt.Fprintf(&builder, "%c%s\n", line.Kind, line.Text)
+		}
+
+		start = end
+	}
+
+	if !changed {
+		return ""
+	}
+
+	return builder.String()
+}
+
+// hunkRange is the start,length of a hunk.  Lines count from 1, and an empty range starts at the
+// line before it.
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// diffLines finds the longest common subsequence of the lines.  The lines which are the same at
+// the start and the end are taken off first, since that is usually most of a manifest.
+func diffLines(oldLines []string, newLines []string) []diffLine {
+	var (
+		prefix int
+		suffix int
+		lines  []diffLine
+	)
+
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range oldLines[:prefix] {
+		lines = append(lines, diffLine{Kind: ' ', Text: text})
+	}
+
+	oldMiddle := oldLines[prefix : len(oldLines)-suffix]
+	newMiddle := newLines[prefix : len(newLines)-suffix]
+
+	// common[i][j] is the length of the longest common subsequence of oldMiddle[i:] and newMiddle[j:]
+	common := make([][]int, len(oldMiddle)+1)
+	for i := range common {
+		common[i] = make([]int, len(newMiddle)+1)
+	}
+	for i := len(oldMiddle) - 1; i >= 0; i-- {
+		for j := len(newMiddle) - 1; j >= 0; j-- {
+			if oldMiddle[i] == newMiddle[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldMiddle) && j < len(newMiddle) {
+		switch {
+		case oldMiddle[i] == newMiddle[j]:
+			lines = append(lines, diffLine{Kind: ' ', Text: oldMiddle[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{Kind: '-', Text: oldMiddle[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{Kind: '+', Text: newMiddle[j]})
+			j++
+		}
+	}
+	for ; i < len(oldMiddle); i++ {
+		lines = append(lines, diffLine{Kind: '-', Text: oldMiddle[i]})
+	}
+	for ; j < len(newMiddle); j++ {
+		lines = append(lines, diffLine{Kind: '+', Text: newMiddle[j]})
+	}
+
+	for _, text := range oldLines[len(oldLines)-suffix:] {
+		lines = append(lines, diffLine{Kind: ' ', Text: text})
+	}
+
+	return lines
+}
@@ -0,0 +1,214 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns the lines "1" to "n".
+func numberedLines(n int) []string {
+	var (
+		lines []string
+	)
+
+	for i := 1; i <= n; i++ {
+		lines = append(lines, strconv.Itoa(i))
+	}
+	return lines
+}
+
+func joinLines(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  []string
+		new  []string
+		want string
+	}{
+		{
+			name: "same",
+			old:  numberedLines(5),
+			new:  numberedLines(5),
+			want: "",
+		},
+		{
+			name: "both empty",
+			want: "",
+		},
+		{
+			name: "change in the middle",
+			old:  numberedLines(10),
+			new:  []string{"1", "2", "3", "4", "five", "6", "7", "8", "9", "10"},
+			want: "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "add at the start",
+			old:  []string{"a", "b"},
+			new:  []string{"new", "a", "b"},
+			want: "--- a/f\n+++ b/f\n@@ -1,2 +1,3 @@\n+new\n a\n b\n",
+		},
+		{
+			name: "remove at the end",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"a", "b"},
+			want: "--- a/f\n+++ b/f\n@@ -1,3 +1,2 @@\n a\n b\n-c\n",
+		},
+		{
+			name: "from empty",
+			new:  []string{"a", "b"},
+			want: "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "to empty",
+			old:  []string{"a"},
+			new:  nil,
+			want: "--- a/f\n+++ b/f\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			// Six lines between the changes, twice the context, are one hunk.
+			name: "merged hunks",
+			old:  numberedLines(12),
+			new:  []string{"1", "two", "3", "4", "5", "6", "7", "8", "nine", "10", "11", "12"},
+			want: "--- a/f\n+++ b/f\n@@ -1,12 +1,12 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n",
+		},
+		{
+			// Seven lines between the changes are two hunks.
+			name: "separate hunks",
+			old:  numberedLines(14),
+			new:  []string{"1", "two", "3", "4", "5", "6", "7", "8", "9", "ten", "11", "12", "13", "14"},
+			want: "--- a/f\n+++ b/f\n@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n@@ -7,7 +7,7 @@\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n",
+		},
+	}
+
+	for _, test := range tests {
+		got := unifiedDiff("a/f", "b/f", joinLines(test.old), joinLines(test.new))
+		if got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+// applyUnifiedDiff applies a diff made by unifiedDiff to the old lines, checking the context and
+// the removed lines against them.
+func applyUnifiedDiff(oldLines []string, diff string) ([]string, error) {
+	var (
+		lines  = strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+		result []string
+		next   int
+	)
+
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "--- ") || !strings.HasPrefix(lines[1], "+++ ") {
+		return nil, fmt.Errorf("no header")
+	}
+
+	for _, line := range lines[2:] {
+		switch {
+		case strings.HasPrefix(line, "@@ "):
+			var (
+				oldStart, oldLen = 0, 1
+			)
+
+			oldRange := strings.TrimPrefix(strings.Fields(line)[1], "-")
+			if before, after, ok := strings.Cut(oldRange, ","); ok {
+				oldStart, _ = strconv.Atoi(before)
+				oldLen, _ = strconv.Atoi(after)
+			} else {
+				oldStart, _ = strconv.Atoi(oldRange)
+			}
+			if oldLen > 0 {
+				oldStart--
+			}
+			if oldStart < next {
+				return nil, fmt.Errorf("hunk %s overlaps", line)
+			}
+			result = append(result, oldLines[next:oldStart]...)
+			next = oldStart
+
+		case line[0] == ' ' || line[0] == '-':
+			if next >= len(oldLines) || oldLines[next] != line[1:] {
+				return nil, fmt.Errorf("line %d is not %q", next+1, line[1:])
+			}
+			if line[0] == ' ' {
+				result = append(result, line[1:])
+			}
+			next++
+
+		case line[0] == '+':
+			result = append(result, line[1:])
+
+		default:
+			return nil, fmt.Errorf("bad line %q", line)
+		}
+	}
+
+	return append(result, oldLines[next:]...), nil
+}
+
+func TestUnifiedDiffApplies(t *testing.T) {
+	var (
+		random = rand.New(rand.NewSource(1))
+		words  = []string{"a", "b", "c", "d"}
+	)
+
+	for n := 0; n < 500; n++ {
+		var (
+			oldLines []string
+			newLines []string
+		)
+
+		for i := random.Intn(40); i > 0; i-- {
+			oldLines = append(oldLines, words[random.Intn(len(words))])
+		}
+		for _, line := range oldLines {
+			switch random.Intn(10) {
+			case 0:
+				// removed
+			case 1:
+				newLines = append(newLines, words[random.Intn(len(words))], line)
+			case 2:
+				newLines = append(newLines, "changed")
+			default:
+				newLines = append(newLines, line)
+			}
+		}
+
+		diff := unifiedDiff("a/f", "b/f", joinLines(oldLines), joinLines(newLines))
+		if diff == "" {
+			if strings.Join(oldLines, "\n") != strings.Join(newLines, "\n") {
+				t.Fatalf("no diff from %q to %q", oldLines, newLines)
+			}
+			continue
+		}
+
+		got, err := applyUnifiedDiff(oldLines, diff)
+		if err != nil {
+			t.Fatalf("applying the diff from %q to %q: %v\n%s", oldLines, newLines, err, diff)
+		}
+		if strings.Join(got, "\n") != strings.Join(newLines, "\n") {
+			t.Fatalf("the diff from %q to %q gives %q\n%s", oldLines, newLines, got, diff)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// The stats listener which haproxyCfg configures on the bastion.
+const (
+	haproxyStatsPort     = 9000
+	haproxyStatsURI      = "/haproxy_stats"
+	haproxyStatsUsername = "Username"
+	haproxyStatsPassword = "Password"
+)
+
+// haproxyProxy is one of the listen sections in haproxy.cfg, and which of the cluster's servers
+// are its backends.
+type haproxyProxy struct {
+	Name    string
+	Port    int
+	Matches func(name string, infraID string) bool
+}
+
+var haproxyProxies = []haproxyProxy{
+	{Name: "ingress-http", Port: 80, Matches: isWorkerServer},
+	{Name: "ingress-https", Port: 443, Matches: isWorkerServer},
+	{Name: "api", Port: 6443, Matches: isControlPlaneServer},
+	{Name: "machine-config-server", Port: 22623, Matches: isControlPlaneServer},
+}
+
+func isWorkerServer(name string, infraID string) bool {
+	return strings.HasPrefix(strings.ToLower(name), fmt.Sprintf("%s-worker-", infraID))
+}
+
+func isControlPlaneServer(name string, infraID string) bool {
+	name = strings.ToLower(name)
+
+	if !strings.HasPrefix(name, infraID) {
+		return false
+	}
+	return strings.Contains(name, "bootstrap") || strings.Contains(name, "master")
+}
+
+// haproxyBackend is a server line in a listen section.
+type haproxyBackend struct {
+	Proxy   string
+	Server  string
+	Address string
+}
+
+// haproxyBackends is every server line haproxy.cfg should have for the cluster, in the order of
+// haproxyProxies.  A server without an IP address yet is left out.
+func haproxyBackends(allServers []servers.Server, infraID string) []haproxyBackend {
+	var (
+		backends = make([]haproxyBackend, 0)
+	)
+
+	for _, proxy := range haproxyProxies {
+		for _, server := range allServers {
+			if !proxy.Matches(server.Name, infraID) {
+				continue
+			}
+
+			macAddr, ipAddress, err := findIpAddress(server)
+			if err != nil || macAddr == "" || ipAddress == "" {
+				continue
+			}
+
+			backends = append(backends, haproxyBackend{
+				Proxy:   proxy.Name,
+				Server:  server.Name,
+				Address: net.JoinHostPort(ipAddress, strconv.Itoa(proxy.Port)),
+			})
+		}
+	}
+
+	return backends
+}
+
+// haproxyStat is one row of the HAProxy CSV stats.  Server is FRONTEND, BACKEND or the name of a
+// server.
+type haproxyStat struct {
+	Proxy         string
+	Server        string
+	Status        string
+	CheckStatus   string
+	CheckFailures int64
+	Sessions      int64
+	TotalSessions int64
+}
+
+// fetchHAProxyStats asks the stats listener on the bastion for its CSV.  The request goes
+// through the ssh connection, so the stats port does not need to be reachable from here.
+func fetchHAProxyStats(ctx context.Context, target sshTarget) ([]byte, error) {
+	var (
+		client    *ssh.Client
+		transport *http.Transport
+		url       = fmt.Sprintf("http://127.0.0.1:%d%s;csv", haproxyStatsPort, haproxyStatsURI)
+		request   *http.Request
+		response  *http.Response
+		body      []byte
+		err       error
+	)
+
+	fmt.Fprintln(sshEcho, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	fmt.Fprintf(sshEcho, "%s: GET %s\n", target, url)
+
+	client, err = sshConnect(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	transport = &http.Transport{
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			return client.DialContext(ctx, network, address)
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	request, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(haproxyStatsUsername, haproxyStatsPassword)
+
+	response, err = (&http.Client{Transport: transport}).Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err = io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error: GET %s returns %s", url, response.Status)
+	}
+
+	return body, nil
+}
+
+// parseHAProxyStats reads the CSV, whose first line is the header prefixed with "# ".
+func parseHAProxyStats(data []byte) ([]haproxyStat, error) {
+	var (
+		reader  *csv.Reader
+		header  []string
+		columns = make(map[string]int)
+		records [][]string
+		stats   []haproxyStat
+		err     error
+	)
+
+	data = bytes.TrimPrefix(data, []byte("# "))
+
+	reader = csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err = reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not read the HAProxy stats header: %v", err)
+	}
+	for i, column := range header {
+		columns[column] = i
+	}
+	for _, column := range []string{"pxname", "svname", "status", "check_status", "chkfail", "scur", "stot"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("Error: The HAProxy stats have no %s column", column)
+		}
+	}
+
+	records, err = reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not read the HAProxy stats: %v", err)
+	}
+
+	for _, record := range records {
+		field := func(column string) string {
+			if columns[column] < len(record) {
+				return record[columns[column]]
+			}
+			return ""
+		}
+		number := func(column string) int64 {
+			n, _ := strconv.ParseInt(field(column), 10, 64)
+			return n
+		}
+
+		stats = append(stats, haproxyStat{
+			Proxy:         field("pxname"),
+			Server:        field("svname"),
+			Status:        field("status"),
+			CheckStatus:   field("check_status"),
+			CheckFailures: number("chkfail"),
+			Sessions:      number("scur"),
+			TotalSessions: number("stot"),
+		})
+	}
+
+	return stats, nil
+}
+
+// haproxyFindings reports every proxy and its servers, and compares the servers with the backends
+// the live server list says there should be.  A nil backends skips the comparison.
+func haproxyFindings(stats []haproxyStat, backends []haproxyBackend) []Finding {
+	var (
+		findings []Finding
+	)
+
+	for _, proxy := range haproxyProxies {
+		var (
+			proxyName = "haproxy/" + proxy.Name
+			found     = false
+			expected  = make(map[string]haproxyBackend)
+			seen      = make(map[string]bool)
+			finding   Finding
+		)
+
+		for _, backend := range backends {
+			if backend.Proxy == proxy.Name {
+				expected[backend.Server] = backend
+			}
+		}
+
+		for _, stat := range stats {
+			if stat.Proxy != proxy.Name {
+				continue
+			}
+
+			switch stat.Server {
+			case "FRONTEND":
+				continue
+			case "BACKEND":
+				found = true
+				if stat.Status == "UP" {
+					finding = newFinding(proxyName, SeverityInfo, "Proxy %s is %s with %d current and %d total sessions", proxy.Name, stat.Status, stat.Sessions, stat.TotalSessions)
+				} else {
+					finding = newFinding(proxyName, SeverityError, "Proxy %s is %s", proxy.Name, stat.Status)
+				}
+				finding.Fields = map[string]string{"status": stat.Status}
+				findings = append(findings, finding)
+				continue
+			}
+
+			seen[stat.Server] = true
+			serverName := proxyName + "/" + stat.Server
+
+			switch {
+			case backends != nil && expected[stat.Server].Server == "":
+				finding = newFinding(serverName, SeverityWarning, "Server %s in %s is not a server of the cluster any more", stat.Server, proxy.Name)
+			case stat.Status == "UP":
+				finding = newFinding(serverName, SeverityInfo, "Server %s in %s is %s, %d check failures, %d current and %d total sessions", stat.Server, proxy.Name, stat.Status, stat.CheckFailures, stat.Sessions, stat.TotalSessions)
+			case stat.Status == "DOWN":
+				finding = newFinding(serverName, SeverityError, "Server %s in %s is %s (%s), %d check failures", stat.Server, proxy.Name, stat.Status, stat.CheckStatus, stat.CheckFailures)
+			default:
+				finding = newFinding(serverName, SeverityWarning, "Server %s in %s is %s (%s), %d check failures", stat.Server, proxy.Name, stat.Status, stat.CheckStatus, stat.CheckFailures)
+			}
+			finding.Fields = map[string]string{"status": stat.Status}
+			findings = append(findings, finding)
+		}
+
+		if !found {
+			finding = newFinding(proxyName, SeverityError, "HAProxy has no %s proxy", proxy.Name)
+			finding.Fields = map[string]string{"status": "missing"}
+			findings = append(findings, finding)
+		}
+
+		for _, backend := range backends {
+			if backend.Proxy != proxy.Name || seen[backend.Server] {
+				continue
+			}
+
+			finding = newFinding(proxyName+"/"+backend.Server, SeverityError, "Server %s (%s) is missing from %s", backend.Server, backend.Address, proxy.Name)
+			finding.Fields = map[string]string{"status": "missing"}
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
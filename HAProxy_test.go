@@ -0,0 +1,180 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// testHAProxyStats is the start of every line of GET /haproxy?stats;csv on a bastion.  HAProxy
+// ends every line with a comma.
+const testHAProxyStats = `# pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,chkfail,chkdown,lastchg,downtime,qlimit,pid,iid,sid,throttle,lbtot,tracked,type,rate,rate_lim,rate_max,check_status,check_code,check_duration,
+stats,FRONTEND,0,0,1,0,,12,0,0,,0,,0,0,0,0,OPEN,,,,,,,,,1,1,,,0,,0,0,,0,,,,
+stats,BACKEND,0,0,0,0,,0,0,0,,0,,0,0,0,0,UP,,,,,,,,,1,1,,,0,,1,0,,0,,,,
+ingress-http,FRONTEND,0,0,0,0,,40,0,0,,0,,0,0,0,0,OPEN,,,,,,,,,1,1,,,0,,0,0,,0,,,,
+ingress-http,test-abc12-worker-0-x7k2p,0,0,0,0,,20,0,0,,0,,0,0,0,0,UP,,,,0,,,,,1,1,,,0,,2,0,,0,L4OK,,0,
+ingress-http,BACKEND,0,0,0,0,,20,0,0,,0,,0,0,0,0,UP,,,,,,,,,1,1,,,0,,1,0,,0,,,,
+ingress-https,FRONTEND,0,0,2,0,,95,0,0,,0,,0,0,0,0,OPEN,,,,,,,,,1,1,,,0,,0,0,,0,,,,
+ingress-https,test-abc12-worker-0-x7k2p,0,0,2,0,,95,0,0,,0,,0,0,0,0,UP,,,,0,,,,,1,1,,,0,,2,0,,0,L4OK,,0,
+ingress-https,BACKEND,0,0,2,0,,95,0,0,,0,,0,0,0,0,UP,,,,,,,,,1,1,,,0,,1,0,,0,,,,
+api,FRONTEND,0,0,30,0,,5120,0,0,,0,,0,0,0,0,OPEN,,,,,,,,,1,1,,,0,,0,0,,0,,,,
+api,test-abc12-master-0,0,0,30,0,,5000,0,0,,0,,0,0,0,0,UP,,,,1,,,,,1,1,,,0,,2,0,,0,L4OK,,0,
+api,test-abc12-master-1,0,0,0,0,,120,0,0,,0,,0,0,0,0,DOWN,,,,7,,,,,1,1,,,0,,2,0,,0,L4CON,,1,
+api,BACKEND,0,0,30,0,,5120,0,0,,0,,0,0,0,0,UP,,,,,,,,,1,1,,,0,,1,0,,0,,,,
+machine-config-server,FRONTEND,0,0,0,0,,8,0,0,,0,,0,0,0,0,OPEN,,,,,,,,,1,1,,,0,,0,0,,0,,,,
+machine-config-server,test-abc12-bootstrap,0,0,0,0,,3,0,0,,0,,0,0,0,0,MAINT,,,,0,,,,,1,1,,,0,,2,0,,0,,,,
+machine-config-server,test-abc12-master-0,0,0,0,0,,5,0,0,,0,,0,0,0,0,UP,,,,0,,,,,1,1,,,0,,2,0,,0,L7OK,,2,
+machine-config-server,BACKEND,0,0,0,0,,8,0,0,,0,,0,0,0,0,UP,,,,,,,,,1,1,,,0,,1,0,,0,,,,
+`
+
+func TestParseHAProxyStats(t *testing.T) {
+	stats, err := parseHAProxyStats([]byte(testHAProxyStats))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 16 {
+		t.Fatalf("got %d rows, want 16", len(stats))
+	}
+
+	want := haproxyStat{
+		Proxy:         "api",
+		Server:        "test-abc12-master-1",
+		Status:        "DOWN",
+		CheckStatus:   "L4CON",
+		CheckFailures: 7,
+		Sessions:      0,
+		TotalSessions: 120,
+	}
+	if stats[10] != want {
+		t.Errorf("got %+v, want %+v", stats[10], want)
+	}
+	if stats[8].Proxy != "api" || stats[8].Server != "FRONTEND" || stats[8].Sessions != 30 || stats[8].TotalSessions != 5120 {
+		t.Errorf("got %+v for the api frontend", stats[8])
+	}
+
+	// Without the "# " the first column is still pxname.
+	stats, err = parseHAProxyStats([]byte(strings.TrimPrefix(testHAProxyStats, "# ")))
+	if err != nil || len(stats) != 16 || stats[0].Proxy != "stats" {
+		t.Errorf("without the # prefix: %v, %+v", err, stats)
+	}
+
+	for name, data := range map[string]string{
+		"empty":          "",
+		"missing column": "# pxname,svname,status,\napi,BACKEND,UP,\n",
+	} {
+		_, err = parseHAProxyStats([]byte(data))
+		if err == nil {
+			t.Errorf("%s: parseHAProxyStats succeeded", name)
+		}
+	}
+}
+
+func TestHAProxyFindings(t *testing.T) {
+	stats, err := parseHAProxyStats([]byte(testHAProxyStats))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backends := []haproxyBackend{
+		{Proxy: "ingress-http", Server: "test-abc12-worker-0-x7k2p", Address: "10.0.0.20:80"},
+		{Proxy: "ingress-https", Server: "test-abc12-worker-0-x7k2p", Address: "10.0.0.20:443"},
+		{Proxy: "api", Server: "test-abc12-master-0", Address: "10.0.0.10:6443"},
+		{Proxy: "api", Server: "test-abc12-master-1", Address: "10.0.0.11:6443"},
+		{Proxy: "api", Server: "test-abc12-master-2", Address: "10.0.0.12:6443"},
+		{Proxy: "machine-config-server", Server: "test-abc12-master-0", Address: "10.0.0.10:22623"},
+	}
+
+	want := []struct {
+		name     string
+		severity Severity
+		status   string
+	}{
+		{"haproxy/ingress-http/test-abc12-worker-0-x7k2p", SeverityInfo, "UP"},
+		{"haproxy/ingress-http", SeverityInfo, "UP"},
+		{"haproxy/ingress-https/test-abc12-worker-0-x7k2p", SeverityInfo, "UP"},
+		{"haproxy/ingress-https", SeverityInfo, "UP"},
+		{"haproxy/api/test-abc12-master-0", SeverityInfo, "UP"},
+		{"haproxy/api/test-abc12-master-1", SeverityError, "DOWN"},
+		{"haproxy/api", SeverityInfo, "UP"},
+		{"haproxy/api/test-abc12-master-2", SeverityError, "missing"},
+		{"haproxy/machine-config-server/test-abc12-bootstrap", SeverityWarning, "MAINT"},
+		{"haproxy/machine-config-server/test-abc12-master-0", SeverityInfo, "UP"},
+		{"haproxy/machine-config-server", SeverityInfo, "UP"},
+	}
+
+	findings := haproxyFindings(stats, backends)
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i].Name != want[i].name || findings[i].Severity != want[i].severity || findings[i].Fields["status"] != want[i].status {
+			t.Errorf("finding %d is %s %s %s, want %s %s %s", i, findings[i].Name, findings[i].Severity, findings[i].Fields["status"], want[i].name, want[i].severity, want[i].status)
+		}
+	}
+
+	if message := findings[5].Message; !strings.Contains(message, "DOWN (L4CON), 7 check failures") {
+		t.Errorf("the DOWN server says %q", message)
+	}
+	if message := findings[7].Message; !strings.Contains(message, "10.0.0.12:6443") {
+		t.Errorf("the missing server says %q", message)
+	}
+	if message := findings[8].Message; !strings.Contains(message, "not a server of the cluster any more") {
+		t.Errorf("the bootstrap server says %q", message)
+	}
+}
+
+func TestHAProxyFindingsWithoutBackends(t *testing.T) {
+	var (
+		stats []haproxyStat
+	)
+
+	all, err := parseHAProxyStats([]byte(testHAProxyStats))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The ingress-https proxy is gone and the api one is down.
+	for _, stat := range all {
+		if stat.Proxy == "ingress-https" {
+			continue
+		}
+		if stat.Proxy == "api" && stat.Server == "BACKEND" {
+			stat.Status = "DOWN"
+		}
+		stats = append(stats, stat)
+	}
+
+	severities := make(map[string]Severity)
+	for _, finding := range haproxyFindings(stats, nil) {
+		severities[finding.Name] = finding.Severity
+	}
+
+	want := map[string]Severity{
+		"haproxy/ingress-https":                              SeverityError,
+		"haproxy/api":                                        SeverityError,
+		"haproxy/api/test-abc12-master-1":                    SeverityError,
+		"haproxy/machine-config-server/test-abc12-bootstrap": SeverityWarning,
+		"haproxy/machine-config-server/test-abc12-master-0":  SeverityInfo,
+	}
+	for name, severity := range want {
+		if severities[name] != severity {
+			t.Errorf("%s has severity %q, want %q", name, severities[name], severity)
+		}
+	}
+	if _, ok := severities["haproxy/api/test-abc12-master-2"]; ok {
+		t.Errorf("without backends, a missing server is reported")
+	}
+}
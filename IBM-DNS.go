@@ -82,8 +82,9 @@ func innerNewIBMDNS(services *Services) ([]*IBMDNS, []error) {
 
 	dnsSvc, dnsRecordsSvc, err = initIBMDNSService(services)
 	if err != nil {
+		// Do not return a nil object which would be asked for its status
 		errs[0] = err
-		return dns, errs
+		return nil, errs
 	}
 
 	dns[0] = &IBMDNS{
@@ -203,10 +204,9 @@ func initIBMDNSService(services *Services) (*dnssvcsv1.DnsSvcsV1, *dnsrecordsv1.
 }
 
 // listDNSRecords lists IBMDNS records for the cluster.
-func (dns *IBMDNS) listIBMDNSRecords() ([]string, error) {
+func (dns *IBMDNS) listIBMDNSRecords(ctx context.Context) ([]string, error) {
 	var (
 		metadata *Metadata
-		ctx      context.Context
 		cancel   context.CancelFunc
 		result   []string
 	)
@@ -215,7 +215,7 @@ func (dns *IBMDNS) listIBMDNSRecords() ([]string, error) {
 
 	metadata = dns.services.GetMetadata()
 
-	ctx, cancel = dns.services.GetContextWithTimeout()
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	select {
@@ -312,30 +312,28 @@ func (dns *IBMDNS) Run() error {
 	return nil
 }
 
-func (dns *IBMDNS) ClusterStatus() {
+func (dns *IBMDNS) ClusterStatus(ctx context.Context) CheckResult {
 	var (
 		metadata *Metadata
 		records  []string
 		patterns = []string{"api-int", "api", "*.apps"}
 		name     string
 		found    bool
+		finding  Finding
+		findings []Finding
 		err      error
 	)
 
-	fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
-
 	metadata = dns.services.GetMetadata()
 
-	records, err = dns.listIBMDNSRecords()
+	records, err = dns.listIBMDNSRecords(ctx)
 	if err != nil {
-		fmt.Printf("%s is NOTOK. Could not list IBMDNS records: %v\n", IBMDNSName, err)
-		return
+		return unknownCheckResult(IBMDNSName, "Could not list IBMDNS records: %v", err)
 	}
 	log.Debugf("Valid: records = %+v", records)
 
 	if len(records) != 3 {
-		fmt.Printf("%s is NOTOK. Expecting 3 IBMDNS records, found %d (%+v)\n", IBMDNSName, len(records), records)
-		return
+		findings = append(findings, newFinding(IBMDNSName, SeverityError, "Expecting 3 IBMDNS records, found %d (%+v)", len(records), records))
 	}
 
 	for _, pattern := range patterns {
@@ -349,16 +347,25 @@ func (dns *IBMDNS) ClusterStatus() {
 			}
 		}
 		if !found {
-			fmt.Printf("%s is NOTOK. Expecting IBMDNS record %s to exist\n", IBMDNSName, name)
-			return
+			finding = newFinding(name, SeverityError, "Expecting IBMDNS record %s to exist", name)
+			finding.Fields = map[string]string{"record": "missing"}
+			findings = append(findings, finding)
+			continue
 		}
 
-		// @TODO maybe do a IBMDNS lookup on the name?
+		// The Domain Name Resolution check looks the name up.
+		finding = newFinding(name, SeverityInfo, "IBMDNS record %s exists", name)
+		finding.Fields = map[string]string{"record": "present"}
+		findings = append(findings, finding)
 	}
 
-	fmt.Printf("%s is OK.\n", IBMDNSName)
+	return newCheckResult(findings)
 }
 
 func (dns *IBMDNS) Priority() (int, error) {
 	return -1, nil
 }
+
+func (dns *IBMDNS) Dependencies() []string {
+	return nil
+}
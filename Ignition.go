@@ -0,0 +1,290 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/containers"
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/objects"
+)
+
+const (
+	IgnitionName = "Bootstrap Ignition"
+
+	// What the installer logs once the cluster is up
+	installCompleteMessage = "Install complete!"
+)
+
+type Ignition struct {
+	services *Services
+}
+
+func NewIgnition(services *Services) ([]RunnableObject, []error) {
+	var (
+		igns []*Ignition
+		errs []error
+		ros  []RunnableObject
+	)
+
+	igns, errs = innerNewIgnition(services)
+
+	ros = make([]RunnableObject, len(igns))
+	// Go does not support type converting the entire array.
+	// So we do it manually.
+	for i, v := range igns {
+		ros[i] = RunnableObject(v)
+	}
+
+	return ros, errs
+}
+
+func NewIgnitionAlt(services *Services) ([]*Ignition, []error) {
+	return innerNewIgnition(services)
+}
+
+func innerNewIgnition(services *Services) ([]*Ignition, []error) {
+	var (
+		igns []*Ignition
+		errs []error
+	)
+
+	igns = make([]*Ignition, 1)
+	errs = make([]error, 1)
+
+	igns[0] = &Ignition{
+		services: services,
+	}
+
+	return igns, errs
+}
+
+func (ign *Ignition) Name() (string, error) {
+	return IgnitionName, nil
+}
+
+func (ign *Ignition) ObjectName() (string, error) {
+	return IgnitionName, nil
+}
+
+func (ign *Ignition) Run() error {
+	// Nothing needs to be done here.
+	return nil
+}
+
+// ClusterStatus checks what createClusterPhase4 uploaded: the <infraID>-ignition container and
+// the object of the same name inside of it.
+func (ign *Ignition) ClusterStatus(ctx context.Context) CheckResult {
+	var (
+		cancel          context.CancelFunc
+		cloud           string
+		infraID         string
+		containerName   string
+		objectName      string
+		connObjectStore *gophercloud.ServiceClient
+		allServers      []servers.Server
+		bootstrapGone   bool
+		installComplete bool
+		containerHeader *containers.GetHeader
+		objectHeader    *objects.GetHeader
+		public          bool
+		finding         Finding
+		findings        []Finding
+		err             error
+	)
+
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cloud = ign.services.GetCloud()
+	infraID = ign.services.GetMetadata().GetInfraID()
+	containerName = fmt.Sprintf("%s-ignition", infraID)
+	objectName = containerName
+	log.Debugf("ClusterStatus: containerName = %s", containerName)
+
+	connObjectStore, err = getServiceClient(ctx, "object-store", cloud)
+	if err != nil {
+		return unknownCheckResult(IgnitionName, "getServiceClient returns error %v", err)
+	}
+
+	allServers, err = getAllServers(ctx, cloud)
+	if err != nil {
+		return unknownCheckResult(IgnitionName, "getAllServers returns error %v", err)
+	}
+
+	bootstrapGone = !hasBootstrapServer(allServers, infraID)
+	installComplete = installerFinished(ign.services.GetInstallDirectory())
+	log.Debugf("ClusterStatus: bootstrapGone = %v, installComplete = %v", bootstrapGone, installComplete)
+
+	containerHeader, err = containers.Get(ctx, connObjectStore, containerName, nil).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		if bootstrapGone {
+			finding = newFinding(containerName, SeverityInfo, "Container %s was removed after the bootstrap VM", containerName)
+		} else {
+			finding = newFinding(containerName, SeverityError, "Container %s does not exist but the bootstrap VM needs it", containerName)
+		}
+		finding.Fields = map[string]string{"container": "missing"}
+		return newCheckResult([]Finding{finding})
+	}
+	if err != nil {
+		return unknownCheckResult(containerName, "Getting container %s returns error %v", containerName, err)
+	}
+
+	for _, acl := range containerHeader.Read {
+		// Referrer ACLs such as .r:* allow anonymous reads
+		if strings.HasPrefix(strings.TrimSpace(acl), ".r:") {
+			public = true
+		}
+	}
+
+	switch {
+	case bootstrapGone:
+		finding = newFinding(containerName, SeverityWarning, "Container %s is left over after the bootstrap VM is gone", containerName)
+	default:
+		finding = newFinding(containerName, SeverityInfo, "Container %s exists", containerName)
+	}
+	finding.Fields = map[string]string{
+		"container": "present",
+		"public":    strconv.FormatBool(public),
+	}
+	findings = append(findings, finding)
+
+	if public && installComplete {
+		finding = newFinding(containerName, SeverityWarning, "The bootstrap ignition in %s is still public after the install completed", containerName)
+		findings = append(findings, finding)
+	}
+
+	objectHeader, err = objects.Get(ctx, connObjectStore, containerName, objectName, nil).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		if bootstrapGone {
+			finding = newFinding(objectName, SeverityInfo, "Object %s/%s was removed after the bootstrap VM", containerName, objectName)
+		} else {
+			finding = newFinding(objectName, SeverityError, "Object %s/%s does not exist but the bootstrap VM needs it", containerName, objectName)
+		}
+		finding.Fields = map[string]string{"object": "missing"}
+		findings = append(findings, finding)
+		return newCheckResult(findings)
+	}
+	if err != nil {
+		findings = append(findings, newFinding(objectName, SeverityError, "Getting object %s/%s returns error %v", containerName, objectName, err))
+		result := newCheckResult(findings)
+		result.Status = worseStatus(result.Status, CheckUnknown)
+		return result
+	}
+
+	findings = append(findings, compareIgnition(filepath.Join(ign.services.GetInstallDirectory(), "bootstrap.ign"), containerName, objectName, objectHeader))
+
+	return newCheckResult(findings)
+}
+
+// compareIgnition compares the uploaded object with the local file, if there still is one.  Swift
+// uses the MD5 of the content as the ETag of an object which was not uploaded in segments.
+func compareIgnition(filename string, containerName string, objectName string, objectHeader *objects.GetHeader) Finding {
+	var (
+		finding   Finding
+		file      *os.File
+		hash      = md5.New()
+		localSize int64
+		localETag string
+		err       error
+	)
+
+	finding.Name = objectName
+	finding.Fields = map[string]string{
+		"object": "present",
+		"size":   strconv.FormatInt(objectHeader.ContentLength, 10),
+		"etag":   objectHeader.ETag,
+	}
+
+	file, err = os.Open(filename)
+	if err != nil {
+		log.Debugf("compareIgnition: %v", err)
+		finding.Severity = SeverityInfo
+		finding.Message = fmt.Sprintf("Object %s/%s exists, there is no local %s to compare it with", containerName, objectName, filename)
+		return finding
+	}
+	defer file.Close()
+
+	localSize, err = io.Copy(hash, file)
+	if err != nil {
+		finding.Severity = SeverityWarning
+		finding.Message = fmt.Sprintf("Object %s/%s exists, but reading %s returns error %v", containerName, objectName, filename, err)
+		return finding
+	}
+	localETag = hex.EncodeToString(hash.Sum(nil))
+
+	finding.Fields["localSize"] = strconv.FormatInt(localSize, 10)
+	finding.Fields["localEtag"] = localETag
+
+	if localSize != objectHeader.ContentLength || !strings.EqualFold(localETag, strings.Trim(objectHeader.ETag, "\"")) {
+		finding.Severity = SeverityError
+		finding.Message = fmt.Sprintf("Object %s/%s (%d bytes, ETag %s) does not match %s (%d bytes, ETag %s)",
+			containerName,
+			objectName,
+			objectHeader.ContentLength,
+			objectHeader.ETag,
+			filename,
+			localSize,
+			localETag,
+		)
+		return finding
+	}
+
+	finding.Severity = SeverityInfo
+	finding.Message = fmt.Sprintf("Object %s/%s matches %s", containerName, objectName, filename)
+	return finding
+}
+
+func hasBootstrapServer(allServers []servers.Server, infraID string) bool {
+	for _, server := range allServers {
+		name := strings.ToLower(server.Name)
+
+		if strings.HasPrefix(name, infraID) && strings.Contains(name, "bootstrap") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// installerFinished looks for the message openshift-install logs at the end of a successful
+// "create cluster" in the install directory.
+func installerFinished(directory string) bool {
+	content, err := os.ReadFile(filepath.Join(directory, ".openshift_install.log"))
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(content), installCompleteMessage)
+}
+
+func (ign *Ignition) Priority() (int, error) {
+	return -1, nil
+}
+
+func (ign *Ignition) Dependencies() []string {
+	return nil
+}
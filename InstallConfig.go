@@ -0,0 +1,331 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// The same networks as scripts/create-cluster.sh
+	defaultClusterNetworkCIDR = "10.116.0.0/14"
+	defaultHostPrefix         = 23
+	defaultServiceNetworkCIDR = "172.30.0.0/16"
+
+	// The smallest control plane node OpenShift supports
+	minimumControlPlaneVCPUs = 4
+	minimumControlPlaneRAM   = 16384
+)
+
+var (
+	dnsLabelRegexp     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dnsSubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// installConfig is the part of the openshift-install install-config.yaml which a PowerVC cluster
+// uses.  The powervc platform is turned into the openstack platform by createClusterPhase2.
+type installConfig struct {
+	APIVersion      string                  `json:"apiVersion"`
+	BaseDomain      string                  `json:"baseDomain"`
+	Compute         []installConfigPool     `json:"compute"`
+	ControlPlane    installConfigPool       `json:"controlPlane"`
+	Metadata        installConfigMetadata   `json:"metadata"`
+	Networking      installConfigNetworking `json:"networking"`
+	Platform        installConfigPlatform   `json:"platform"`
+	CredentialsMode string                  `json:"credentialsMode"`
+	PullSecret      string                  `json:"pullSecret"`
+	SSHKey          string                  `json:"sshKey"`
+}
+
+type installConfigPool struct {
+	Architecture   string                    `json:"architecture"`
+	Hyperthreading string                    `json:"hyperthreading"`
+	Name           string                    `json:"name"`
+	Platform       installConfigPoolPlatform `json:"platform"`
+	Replicas       int                       `json:"replicas"`
+}
+
+type installConfigPoolPlatform struct {
+	PowerVC installConfigPowerVCPool `json:"powervc"`
+}
+
+type installConfigPowerVCPool struct {
+	Zones []string `json:"zones"`
+}
+
+type installConfigMetadata struct {
+	Name string `json:"name"`
+}
+
+type installConfigNetworking struct {
+	ClusterNetwork []installConfigClusterNetwork `json:"clusterNetwork"`
+	MachineNetwork []installConfigMachineNetwork `json:"machineNetwork"`
+	NetworkType    string                        `json:"networkType"`
+	ServiceNetwork []string                      `json:"serviceNetwork"`
+}
+
+type installConfigClusterNetwork struct {
+	CIDR       string `json:"cidr"`
+	HostPrefix int    `json:"hostPrefix"`
+}
+
+type installConfigMachineNetwork struct {
+	CIDR string `json:"cidr"`
+}
+
+type installConfigPlatform struct {
+	PowerVC installConfigPowerVC `json:"powervc"`
+}
+
+type installConfigPowerVC struct {
+	LoadBalancer           installConfigLoadBalancer `json:"loadBalancer"`
+	APIVIPs                []string                  `json:"apiVIPs"`
+	Cloud                  string                    `json:"cloud"`
+	ClusterOSImage         string                    `json:"clusterOSImage"`
+	DefaultMachinePlatform installConfigMachineType  `json:"defaultMachinePlatform"`
+	IngressVIPs            []string                  `json:"ingressVIPs"`
+	ControlPlanePort       installConfigPort         `json:"controlPlanePort"`
+}
+
+type installConfigLoadBalancer struct {
+	Type string `json:"type"`
+}
+
+type installConfigMachineType struct {
+	Type string `json:"type"`
+}
+
+type installConfigPort struct {
+	FixedIPs []installConfigFixedIP `json:"fixedIPs"`
+}
+
+type installConfigFixedIP struct {
+	Subnet installConfigSubnet `json:"subnet"`
+}
+
+type installConfigSubnet struct {
+	ID string `json:"id"`
+}
+
+// installConfigInputs is what generate-install-config found in the cloud and read from files.
+type installConfigInputs struct {
+	Cloud            string
+	ClusterName      string
+	BaseDomain       string
+	AvailabilityZone string
+	FlavorName       string
+	ImageName        string
+	SubnetID         string
+	MachineCIDR      string
+	VIP              string
+	Workers          int
+	PullSecret       string
+	SSHKey           string
+}
+
+// newInstallConfig fills in the same install-config.yaml which scripts/create-cluster.sh writes.
+// The bastion is the load balancer, so it is both the API and the ingress VIP.
+func newInstallConfig(inputs installConfigInputs) *installConfig {
+	pool := func(name string, replicas int) installConfigPool {
+		return installConfigPool{
+			Architecture:   rhcosArchitecture,
+			Hyperthreading: "Enabled",
+			Name:           name,
+			Platform: installConfigPoolPlatform{
+				PowerVC: installConfigPowerVCPool{
+					Zones: []string{inputs.AvailabilityZone},
+				},
+			},
+			Replicas: replicas,
+		}
+	}
+
+	return &installConfig{
+		APIVersion:   "v1",
+		BaseDomain:   inputs.BaseDomain,
+		Compute:      []installConfigPool{pool("worker", inputs.Workers)},
+		ControlPlane: pool("master", 3),
+		Metadata: installConfigMetadata{
+			Name: inputs.ClusterName,
+		},
+		Networking: installConfigNetworking{
+			ClusterNetwork: []installConfigClusterNetwork{
+				{CIDR: defaultClusterNetworkCIDR, HostPrefix: defaultHostPrefix},
+			},
+			MachineNetwork: []installConfigMachineNetwork{
+				{CIDR: inputs.MachineCIDR},
+			},
+			NetworkType:    "OVNKubernetes",
+			ServiceNetwork: []string{defaultServiceNetworkCIDR},
+		},
+		Platform: installConfigPlatform{
+			PowerVC: installConfigPowerVC{
+				LoadBalancer:   installConfigLoadBalancer{Type: "UserManaged"},
+				APIVIPs:        []string{inputs.VIP},
+				Cloud:          inputs.Cloud,
+				ClusterOSImage: inputs.ImageName,
+				DefaultMachinePlatform: installConfigMachineType{
+					Type: inputs.FlavorName,
+				},
+				IngressVIPs: []string{inputs.VIP},
+				ControlPlanePort: installConfigPort{
+					FixedIPs: []installConfigFixedIP{
+						{Subnet: installConfigSubnet{ID: inputs.SubnetID}},
+					},
+				},
+			},
+		},
+		CredentialsMode: "Passthrough",
+		PullSecret:      inputs.PullSecret,
+		SSHKey:          inputs.SSHKey,
+	}
+}
+
+// validateInstallConfig checks what openshift-install would otherwise only complain about after
+// it consumed the file.  Every problem is reported, not just the first.
+func validateInstallConfig(config *installConfig) error {
+	var (
+		problems []string
+		machines *net.IPNet
+		err      error
+	)
+
+	if !dnsLabelRegexp.MatchString(config.Metadata.Name) || len(config.Metadata.Name) > 63 {
+		problems = append(problems, fmt.Sprintf("metadata.name %q is not a DNS label", config.Metadata.Name))
+	}
+	if !dnsSubdomainRegexp.MatchString(config.BaseDomain) {
+		problems = append(problems, fmt.Sprintf("baseDomain %q is not a DNS domain", config.BaseDomain))
+	}
+
+	if config.ControlPlane.Replicas < 1 {
+		problems = append(problems, fmt.Sprintf("%s replicas is %d", config.ControlPlane.Name, config.ControlPlane.Replicas))
+	}
+	// No workers is a compact cluster, where the masters are schedulable.
+	for _, pool := range config.Compute {
+		if pool.Replicas < 0 {
+			problems = append(problems, fmt.Sprintf("%s replicas is %d", pool.Name, pool.Replicas))
+		}
+	}
+
+	_, machines, err = net.ParseCIDR(config.Networking.MachineNetwork[0].CIDR)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("machineNetwork %q is not a CIDR", config.Networking.MachineNetwork[0].CIDR))
+	}
+
+	for _, cidr := range []string{config.Networking.ClusterNetwork[0].CIDR, config.Networking.ServiceNetwork[0]} {
+		_, other, err := net.ParseCIDR(cidr)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%q is not a CIDR", cidr))
+			continue
+		}
+		if machines != nil && (machines.Contains(other.IP) || other.Contains(machines.IP)) {
+			problems = append(problems, fmt.Sprintf("%s overlaps the machineNetwork %s", cidr, machines))
+		}
+	}
+
+	for _, vips := range []struct {
+		name string
+		ips  []string
+	}{
+		{"apiVIPs", config.Platform.PowerVC.APIVIPs},
+		{"ingressVIPs", config.Platform.PowerVC.IngressVIPs},
+	} {
+		for _, vip := range vips.ips {
+			ip := net.ParseIP(vip)
+			if ip == nil {
+				problems = append(problems, fmt.Sprintf("%s %q is not an IP address", vips.name, vip))
+				continue
+			}
+			if machines != nil && !machines.Contains(ip) {
+				problems = append(problems, fmt.Sprintf("%s %s is not in the machineNetwork %s", vips.name, vip, machines))
+			}
+		}
+	}
+
+	if config.Platform.PowerVC.ControlPlanePort.FixedIPs[0].Subnet.ID == "" {
+		problems = append(problems, "controlPlanePort has no subnet")
+	}
+
+	err = validatePullSecret(config.PullSecret)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	_, _, _, _, err = ssh.ParseAuthorizedKey([]byte(config.SSHKey))
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("sshKey is not an ssh public key: %v", err))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Error: The install-config is not valid:\n    %s", strings.Join(problems, "\n    "))
+	}
+
+	return nil
+}
+
+// validatePullSecret checks that the pull secret is the JSON from console.redhat.com.
+func validatePullSecret(pullSecret string) error {
+	var (
+		secret struct {
+			Auths map[string]struct {
+				Auth string `json:"auth"`
+			} `json:"auths"`
+		}
+		err error
+	)
+
+	err = json.Unmarshal([]byte(pullSecret), &secret)
+	if err != nil {
+		return fmt.Errorf("pullSecret is not JSON: %v", err)
+	}
+	if len(secret.Auths) == 0 {
+		return fmt.Errorf("pullSecret has no auths")
+	}
+	for registry, auth := range secret.Auths {
+		if auth.Auth == "" {
+			return fmt.Errorf("pullSecret has no auth for %s", registry)
+		}
+	}
+
+	return nil
+}
+
+// marshalInstallConfig validates the install-config and turns it into YAML.
+func marshalInstallConfig(config *installConfig) ([]byte, error) {
+	var (
+		content []byte
+		err     error
+	)
+
+	err = validateInstallConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
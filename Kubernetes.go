@@ -0,0 +1,191 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// The same as oc --request-timeout=5s
+	kubeRequestTimeout = 5 * time.Second
+
+	machineAPINamespace = "openshift-machine-api"
+)
+
+// The OpenShift resources are read with the dynamic client and converted into the types from
+// github.com/openshift/api, so that only client-go is needed.
+var (
+	clusterVersionsResource = schema.GroupVersionResource{
+		Group:    "config.openshift.io",
+		Version:  "v1",
+		Resource: "clusterversions",
+	}
+	clusterOperatorsResource = schema.GroupVersionResource{
+		Group:    "config.openshift.io",
+		Version:  "v1",
+		Resource: "clusteroperators",
+	}
+	machinesResource = schema.GroupVersionResource{
+		Group:    "machine.openshift.io",
+		Version:  "v1beta1",
+		Resource: "machines",
+	}
+	machineSetsResource = schema.GroupVersionResource{
+		Group:    "machine.openshift.io",
+		Version:  "v1beta1",
+		Resource: "machinesets",
+	}
+)
+
+// kubeClients talks to the cluster in a kubeconfig.  The fake clientsets from client-go
+// satisfy the same interfaces.
+type kubeClients struct {
+	kube    kubernetes.Interface
+	dynamic dynamic.Interface
+}
+
+func newKubeClients(kubeConfig string) (*kubeClients, error) {
+	var (
+		config        *rest.Config
+		kubeClient    *kubernetes.Clientset
+		dynamicClient *dynamic.DynamicClient
+		err           error
+	)
+
+	config, err = clientcmd.BuildConfigFromFlags("", kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not load kubeconfig %s: %v", kubeConfig, err)
+	}
+	config.Timeout = kubeRequestTimeout
+
+	kubeClient, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kubeClients{
+		kube:    kubeClient,
+		dynamic: dynamicClient,
+	}, nil
+}
+
+func (kc *kubeClients) getClusterVersion(ctx context.Context) (*configv1.ClusterVersion, error) {
+	var (
+		object         *unstructured.Unstructured
+		clusterVersion configv1.ClusterVersion
+		err            error
+	)
+
+	object, err = kc.dynamic.Resource(clusterVersionsResource).Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &clusterVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clusterVersion, nil
+}
+
+func (kc *kubeClients) listClusterOperators(ctx context.Context) ([]configv1.ClusterOperator, error) {
+	var (
+		list             *unstructured.UnstructuredList
+		clusterOperators []configv1.ClusterOperator
+		err              error
+	)
+
+	list, err = kc.dynamic.Resource(clusterOperatorsResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterOperators = make([]configv1.ClusterOperator, len(list.Items))
+	for i := range list.Items {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), &clusterOperators[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clusterOperators, nil
+}
+
+func (kc *kubeClients) listMachines(ctx context.Context) ([]machinev1beta1.Machine, error) {
+	var (
+		list     *unstructured.UnstructuredList
+		machines []machinev1beta1.Machine
+		err      error
+	)
+
+	list, err = kc.dynamic.Resource(machinesResource).Namespace(machineAPINamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	machines = make([]machinev1beta1.Machine, len(list.Items))
+	for i := range list.Items {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), &machines[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return machines, nil
+}
+
+func (kc *kubeClients) listMachineSets(ctx context.Context) ([]machinev1beta1.MachineSet, error) {
+	var (
+		list        *unstructured.UnstructuredList
+		machineSets []machinev1beta1.MachineSet
+		err         error
+	)
+
+	list, err = kc.dynamic.Resource(machineSetsResource).Namespace(machineAPINamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	machineSets = make([]machinev1beta1.MachineSet, len(list.Items))
+	for i := range list.Items {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), &machineSets[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return machineSets, nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// newFakeOc returns an Oc whose clients are the fake clientsets, holding the nodes and the
+// OpenShift config resources.
+func newFakeOc(t *testing.T, nodes []runtime.Object, configObjects ...any) *Oc {
+	var (
+		unstructuredObjects []runtime.Object
+	)
+
+	for _, object := range configObjects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unstructuredObjects = append(unstructuredObjects, &unstructured.Unstructured{Object: content})
+	}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			clusterVersionsResource:  "ClusterVersionList",
+			clusterOperatorsResource: "ClusterOperatorList",
+			machinesResource:         "MachineList",
+			machineSetsResource:      "MachineSetList",
+		},
+		unstructuredObjects...,
+	)
+
+	return &Oc{
+		clients: &kubeClients{
+			kube:    kubefake.NewClientset(nodes...),
+			dynamic: dynamicClient,
+		},
+	}
+}
+
+func newClusterVersion(conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterVersion {
+	return &configv1.ClusterVersion{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion"},
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Desired:    configv1.Release{Version: "4.20.0"},
+			Conditions: conditions,
+		},
+	}
+}
+
+func newClusterOperator(name string, conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: configv1.ClusterOperatorStatus{
+			Conditions: conditions,
+		},
+	}
+}
+
+func condition(conditionType configv1.ClusterStatusConditionType, status configv1.ConditionStatus, message string) configv1.ClusterOperatorStatusCondition {
+	return configv1.ClusterOperatorStatusCondition{Type: conditionType, Status: status, Message: message}
+}
+
+func TestClusterVersionFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []configv1.ClusterOperatorStatusCondition
+		severity   Severity
+		message    string
+	}{
+		{
+			name: "available",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionTrue, "Done applying 4.20.0"),
+				condition(configv1.OperatorProgressing, configv1.ConditionFalse, ""),
+			},
+			severity: SeverityInfo,
+			message:  "Cluster version 4.20.0 is available",
+		},
+		{
+			name: "progressing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+				condition(configv1.OperatorProgressing, configv1.ConditionTrue, "Working towards 4.20.1"),
+			},
+			severity: SeverityWarning,
+			message:  "Cluster version 4.20.0 is progressing: Working towards 4.20.1",
+		},
+		{
+			name: "not available",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionFalse, "Cluster has no version"),
+				condition(configv1.OperatorProgressing, configv1.ConditionTrue, "Working towards 4.20.0"),
+			},
+			severity: SeverityError,
+			message:  "Cluster version 4.20.0 is not available: Cluster has no version",
+		},
+		{
+			name: "failing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionFalse, ""),
+				condition(clusterVersionFailing, configv1.ConditionTrue, "Cluster operator etcd is degraded"),
+			},
+			severity: SeverityError,
+			message:  "Cluster version 4.20.0 is failing: Cluster operator etcd is degraded",
+		},
+	}
+
+	for _, test := range tests {
+		oc := newFakeOc(t, nil, newClusterVersion(test.conditions...))
+
+		findings, err := oc.clusterVersionFindings(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(findings) != 1 {
+			t.Fatalf("%s: %d findings", test.name, len(findings))
+		}
+		if findings[0].Severity != test.severity || findings[0].Message != test.message {
+			t.Errorf("%s: got %s %q, want %s %q", test.name, findings[0].Severity, findings[0].Message, test.severity, test.message)
+		}
+		if findings[0].Fields["version"] != "4.20.0" {
+			t.Errorf("%s: version field is %q", test.name, findings[0].Fields["version"])
+		}
+	}
+
+	// Without a ClusterVersion the cluster version cannot be checked.
+	oc := newFakeOc(t, nil)
+	_, err := oc.clusterVersionFindings(context.Background())
+	if err == nil {
+		t.Errorf("clusterVersionFindings without a ClusterVersion succeeded")
+	}
+}
+
+func TestClusterOperatorFindings(t *testing.T) {
+	oc := newFakeOc(t, nil,
+		newClusterOperator("network",
+			condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+			condition(configv1.OperatorProgressing, configv1.ConditionFalse, ""),
+			condition(configv1.OperatorDegraded, configv1.ConditionFalse, ""),
+		),
+		newClusterOperator("etcd",
+			condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+			condition(configv1.OperatorDegraded, configv1.ConditionTrue, "NodeControllerDegraded"),
+		),
+		newClusterOperator("ingress",
+			condition(configv1.OperatorAvailable, configv1.ConditionFalse, "No router pods"),
+		),
+		newClusterOperator("dns",
+			condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+			condition(configv1.OperatorProgressing, configv1.ConditionTrue, "Rolling out"),
+		),
+	)
+
+	findings, err := oc.clusterOperatorFindings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name     string
+		severity Severity
+		message  string
+	}{
+		{"co/dns", SeverityWarning, "Cluster operator dns is progressing: Rolling out"},
+		{"co/etcd", SeverityError, "Cluster operator etcd is degraded: NodeControllerDegraded"},
+		{"co/ingress", SeverityError, "Cluster operator ingress is not available: No router pods"},
+		{"co/network", SeverityInfo, "Cluster operator network is available"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i].Name != want[i].name || findings[i].Severity != want[i].severity || findings[i].Message != want[i].message {
+			t.Errorf("finding %d is %s %s %q, want %s %s %q", i, findings[i].Name, findings[i].Severity, findings[i].Message, want[i].name, want[i].severity, want[i].message)
+		}
+	}
+	if findings[3].Fields["available"] != "True" || findings[3].Fields["degraded"] != "False" {
+		t.Errorf("co/network fields are %v", findings[3].Fields)
+	}
+
+	// An installation which has no operators yet is an error.
+	findings, err = newFakeOc(t, nil).clusterOperatorFindings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("without cluster operators: %+v", findings)
+	}
+}
+
+func newNode(name string, ready corev1.ConditionStatus, message string, roles ...string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready, Message: message},
+			},
+			NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.33.0"},
+		},
+	}
+	for _, role := range roles {
+		node.Labels[nodeRolePrefix+role] = ""
+	}
+	return node
+}
+
+func TestNodeFindings(t *testing.T) {
+	oc := newFakeOc(t, []runtime.Object{
+		newNode("worker-0", corev1.ConditionFalse, "container runtime is down", "worker"),
+		newNode("master-0", corev1.ConditionTrue, "", "master", "control-plane"),
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+	})
+
+	findings, err := oc.nodeFindings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name     string
+		severity Severity
+		message  string
+		ready    string
+		roles    string
+	}{
+		{"node/master-0", SeverityInfo, "Node master-0 (control-plane,master) is ready, kubelet v1.33.0", "True", "control-plane,master"},
+		{"node/worker-0", SeverityError, "Node worker-0 (worker) is not ready: container runtime is down", "False", "worker"},
+		{"node/worker-1", SeverityError, "Node worker-1 (<none>) is not ready: ", "Unknown", "<none>"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i].Name != want[i].name || findings[i].Severity != want[i].severity || findings[i].Message != want[i].message {
+			t.Errorf("finding %d is %s %s %q, want %s %s %q", i, findings[i].Name, findings[i].Severity, findings[i].Message, want[i].name, want[i].severity, want[i].message)
+		}
+		if findings[i].Fields["ready"] != want[i].ready || findings[i].Fields["roles"] != want[i].roles {
+			t.Errorf("finding %d has fields %v", i, findings[i].Fields)
+		}
+	}
+
+	findings, err = newFakeOc(t, nil).nodeFindings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("without nodes: %+v", findings)
+	}
+}
@@ -16,12 +16,11 @@ package main
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+
+	"golang.org/x/crypto/ssh"
 )
 
 const (
@@ -84,9 +83,8 @@ func (lbs *LoadBalancer) Run() error {
 	return nil
 }
 
-func (lbs *LoadBalancer) ClusterStatus() {
+func (lbs *LoadBalancer) ClusterStatus(ctx context.Context) CheckResult {
 	var (
-		ctx         context.Context
 		cancel      context.CancelFunc
 		clusterName string
 		cloud       string
@@ -94,11 +92,17 @@ func (lbs *LoadBalancer) ClusterStatus() {
 		ipAddress   string
 		outb        []byte
 		outs        string
-		exitError   *exec.ExitError
+		hostKeys    []ssh.PublicKey
+		target      sshTarget
+		allServers  []servers.Server
+		backends    []haproxyBackend
+		stats       []haproxyStat
+		finding     Finding
+		findings    []Finding
 		err         error
 	)
 
-	ctx, cancel = lbs.services.GetContextWithTimeout()
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	clusterName = lbs.services.GetMetadata().GetClusterName()
@@ -107,48 +111,42 @@ func (lbs *LoadBalancer) ClusterStatus() {
 	cloud = lbs.services.GetMetadata().GetCloud()
 	log.Debugf("ClusterStatus: cloud = %s", cloud)
 	if cloud == "" {
-		fmt.Printf("%s: Error: GetCloud returns empty string\n", LoadBalancerName)
-		return
+		return unknownCheckResult(LoadBalancerName, "GetCloud returns empty string")
 	}
 
 	server, err = findServer(ctx, cloud, clusterName)
 	if err != nil {
-		fmt.Printf("%s: Error: findServer returns error %v\n", LoadBalancerName, err)
-		return
+		return newCheckResult([]Finding{newFinding(clusterName, SeverityError, "findServer returns error %v", err)})
 	}
 	log.Debugf("ClusterStatus: FOUND server = %s", server.Name)
 
 	_, ipAddress, err = findIpAddress(server)
 	if err != nil {
-		fmt.Printf("%s: Error: findIpAddress returns error %v\n", LoadBalancerName, err)
-		return
+		return newCheckResult([]Finding{newFinding(server.Name, SeverityError, "findIpAddress returns error %v", err)})
 	}
 	if ipAddress == "" {
-		fmt.Printf("%s: Error: findIpAddress returns empty string\n", LoadBalancerName)
-		return
+		return newCheckResult([]Finding{newFinding(server.Name, SeverityError, "findIpAddress returns empty string")})
 	}
 	log.Debugf("ClusterStatus: ipAddress = %s", ipAddress)
 
-	outb, err = runSplitCommand2([]string{
-		"ssh-keyscan",
-		ipAddress,
-	})
-	outs = strings.TrimSpace(string(outb))
-	log.Debugf("ClusterStatus: outs = \"%s\"", outs)
-	if errors.As(err, &exitError) {
-		log.Debugf("ClusterStatus: exitError.ExitCode() = %+v\n", exitError.ExitCode())
+	hostKeys, err = scanHostKeys(ctx, ipAddress)
+	log.Debugf("ClusterStatus: len(hostKeys) = %d, err = %v", len(hostKeys), err)
+	if len(hostKeys) == 0 {
+		finding = newFinding(server.Name, SeverityError, "Cluster bastion is not reachable with ssh (%v)", err)
+		finding.Fields = map[string]string{
+			"ipAddress": ipAddress,
+			"ssh":       "DEAD",
+		}
+		return newCheckResult([]Finding{finding})
 	}
-	if outs != "" {
-		fmt.Printf("%s: Cluster bastion is alive\n", LoadBalancerName)
-	} else {
-		return
+
+	target = sshTarget{
+		Host:        ipAddress,
+		Username:    lbs.services.GetBastionUsername(),
+		KeyFilename: lbs.services.GetInstallerRsa(),
 	}
 
-	outb, err = runSplitCommand2([]string{
-		"ssh",
-		"-i",
-		lbs.services.GetInstallerRsa(),
-		fmt.Sprintf("%s@%s", lbs.services.GetBastionUsername(), ipAddress),
+	outb, err = sshRun(ctx, target, []string{
 		"sudo",
 		"systemctl",
 		"status",
@@ -158,13 +156,47 @@ func (lbs *LoadBalancer) ClusterStatus() {
 	})
 	outs = strings.TrimSpace(string(outb))
 	if err != nil {
-		fmt.Printf("%s: Error: Finding haproxy status returns error %v\n", LoadBalancerName, err)
-		return
+		finding = newFinding(server.Name, SeverityError, "Finding haproxy status returns error %v", err)
+	} else {
+		finding = newFinding(server.Name, SeverityInfo, "Cluster bastion is alive and has the following status:")
+	}
+	finding.Fields = map[string]string{
+		"ipAddress": ipAddress,
+		"ssh":       "ALIVE",
+	}
+	finding.Output = outs
+	findings = append(findings, finding)
+
+	// Without the live server list the stats can still be reported, only not compared.
+	allServers, err = getAllServers(ctx, cloud)
+	if err != nil {
+		findings = append(findings, newFinding(server.Name, SeverityWarning, "getAllServers returns error %v", err))
+	} else {
+		backends = haproxyBackends(allServers, lbs.services.GetMetadata().GetInfraID())
 	}
-	fmt.Printf("%s: Cluster bastion has the following status:\n", LoadBalancerName)
-	fmt.Println(outs)
+
+	outb, err = fetchHAProxyStats(ctx, target)
+	if err != nil {
+		findings = append(findings, newFinding(server.Name, SeverityError, "Fetching the HAProxy stats returns error %v", err))
+		return newCheckResult(findings)
+	}
+
+	stats, err = parseHAProxyStats(outb)
+	if err != nil {
+		findings = append(findings, newFinding(server.Name, SeverityError, "%v", err))
+		return newCheckResult(findings)
+	}
+
+	findings = append(findings, haproxyFindings(stats, backends)...)
+
+	return newCheckResult(findings)
 }
 
 func (lbs *LoadBalancer) Priority() (int, error) {
 	return -1, nil
 }
+
+func (lbs *LoadBalancer) Dependencies() []string {
+	// The bastion is one of the cluster's VMs
+	return []string{VMsName}
+}
@@ -0,0 +1,625 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// defaultManifestRules are the changes which the OpenStack manifests need to work on PowerVC:
+// PowerVC has no security groups, the VMs boot the RHCOS image in Glance, and there is no
+// Octavia for the cloud provider to create load balancers with.
+const defaultManifestRules = `
+rules:
+- name: remove-security-groups
+  files:
+  - openshift/*
+  - cluster-api/machines/*
+  patch:
+  - op: remove
+    path: /**/securityGroups
+
+- name: rhcos-image
+  files:
+  - openshift/*
+  - cluster-api/machines/*
+  match:
+  - kind: Machine
+  - kind: MachineSet
+  - kind: ControlPlaneMachineSet
+  - kind: OpenStackMachine
+  patch:
+  - op: replace
+    path: /**/image
+    ifType: object
+    value:
+      filter:
+        name: ${rhcosImageName}
+  - op: replace
+    path: /**/image
+    ifType: string
+    value: ${rhcosImageName}
+
+- name: disable-load-balancer
+  files:
+  - manifests/cloud-provider-config.yaml
+  match:
+  - kind: ConfigMap
+    metadata.name: cloud-provider-config
+  patch:
+  - op: append
+    path: /data/config
+    unlessContains: "[LoadBalancer]"
+    value: |
+      [LoadBalancer]
+      enabled = false
+`
+
+// manifestRules is a rules file.
+type manifestRules struct {
+	Rules []manifestRule `json:"rules"`
+}
+
+// manifestRule patches every manifest which one of Files matches, relative to the installation
+// directory, and which one of Match selects.  A selector maps dotted paths to the values they
+// must have, and no selectors selects every manifest.
+type manifestRule struct {
+	Name  string              `json:"name"`
+	Files []string            `json:"files"`
+	Match []map[string]string `json:"match,omitempty"`
+	Patch []patchOperation    `json:"patch"`
+}
+
+// patchOperation is like a JSON Patch operation, except that a * in Path matches any one key or
+// index, and a ** matches any number of them.  Every location Path matches is changed.
+//
+//	add      sets the key, whether it exists or not
+//	replace  sets the key if it exists
+//	remove   deletes the key if it exists
+//	append   adds Value to the end of the string at the key
+//
+// IfType only changes values of that JSON type: string, number, boolean, object, array or
+// null.  UnlessContains skips strings which already contain it.
+type patchOperation struct {
+	Op             string `json:"op"`
+	Path           string `json:"path"`
+	Value          any    `json:"value,omitempty"`
+	IfType         string `json:"ifType,omitempty"`
+	UnlessContains string `json:"unlessContains,omitempty"`
+}
+
+var (
+	manifestRuleVariable = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+	// Set by create-cluster.  Empty uses defaultManifestRules.
+	manifestRulesFilename string
+)
+
+// loadManifestRules reads a rules file, in YAML or JSON, or the default rules when filename is
+// empty.
+func loadManifestRules(filename string) (*manifestRules, error) {
+	var (
+		content = []byte(defaultManifestRules)
+		rules   manifestRules
+		err     error
+	)
+
+	if filename != "" {
+		content, err = os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Error: Could not read %s: %v", filename, err)
+		}
+	} else {
+		filename = "the default manifest rules"
+	}
+
+	err = yaml.UnmarshalStrict(content, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse %s: %v", filename, err)
+	}
+
+	for _, rule := range rules.Rules {
+		if len(rule.Files) == 0 {
+			return nil, fmt.Errorf("Error: Rule %s in %s has no files", rule.Name, filename)
+		}
+		for _, operation := range rule.Patch {
+			switch operation.Op {
+			case "add", "replace", "remove", "append":
+			default:
+				return nil, fmt.Errorf("Error: Rule %s in %s has an unknown op %s", rule.Name, filename, operation.Op)
+			}
+			if !strings.HasPrefix(operation.Path, "/") {
+				return nil, fmt.Errorf("Error: Rule %s in %s has a path which does not start with / (%s)", rule.Name, filename, operation.Path)
+			}
+		}
+	}
+	log.Debugf("loadManifestRules: %s has %d rules", filename, len(rules.Rules))
+
+	return &rules, nil
+}
+
+// manifestEdit is one file which the rules change, before and after.
+type manifestEdit struct {
+	Filename string
+	Old      []byte
+	New      []byte
+	Rules    []string
+}
+
+// planManifestRules works out what the rules change in the manifests under directory, without
+// writing anything.  ${name} in a value is replaced with vars[name].
+func planManifestRules(directory string, rules *manifestRules, vars map[string]string) ([]manifestEdit, error) {
+	var (
+		filenames []string
+		matched   = make(map[string][]int)
+		edits     []manifestEdit
+		err       error
+	)
+
+	for i, rule := range rules.Rules {
+		for _, pattern := range rule.Files {
+			var (
+				globbed []string
+			)
+
+			globbed, err = filepath.Glob(filepath.Join(directory, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("Error: Rule %s has a bad file pattern %s: %v", rule.Name, pattern, err)
+			}
+
+			for _, filename := range globbed {
+				if info, err := os.Stat(filename); err != nil || info.IsDir() {
+					continue
+				}
+				if _, ok := matched[filename]; !ok {
+					filenames = append(filenames, filename)
+				}
+				if len(matched[filename]) == 0 || matched[filename][len(matched[filename])-1] != i {
+					matched[filename] = append(matched[filename], i)
+				}
+			}
+		}
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		var (
+			edit = manifestEdit{Filename: filename}
+			node map[string]any
+		)
+
+		edit.Old, err = os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading YAML file: %v", err)
+		}
+
+		node, err = yamlToMap(edit.Old)
+		if err != nil {
+			return nil, fmt.Errorf("Error: %s: %v", filename, err)
+		}
+
+		for _, i := range matched[filename] {
+			var (
+				rule    = rules.Rules[i]
+				changed bool
+			)
+
+			if !selectsManifest(node, rule.Match) {
+				continue
+			}
+
+			changed, err = applyManifestRule(node, rule, vars)
+			if err != nil {
+				return nil, fmt.Errorf("Error: Rule %s on %s: %v", rule.Name, filename, err)
+			}
+			if changed {
+				log.Debugf("planManifestRules: rule %s changes %s", rule.Name, filename)
+				edit.Rules = append(edit.Rules, rule.Name)
+			}
+		}
+
+		if len(edit.Rules) == 0 {
+			continue
+		}
+
+		edit.New, err = mapToYAML(node)
+		if err != nil {
+			return nil, err
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return edits, nil
+}
+
+// writeManifestEdits writes the changed files.
+func writeManifestEdits(edits []manifestEdit) error {
+	var (
+		err error
+	)
+
+	for _, edit := range edits {
+		fmt.Printf("%s: %s\n", edit.Filename, strings.Join(edit.Rules, ", "))
+
+		err = os.WriteFile(edit.Filename, edit.New, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func yamlToMap(content []byte) (map[string]any, error) {
+	var (
+		abyteJson []byte
+		node      map[string]any
+		err       error
+	)
+
+	abyteJson, err = yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert yaml to json: %v", err)
+	}
+
+	err = json.Unmarshal(abyteJson, &node)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal the json: %v", err)
+	}
+
+	return node, nil
+}
+
+func mapToYAML(node map[string]any) ([]byte, error) {
+	var (
+		abyteJson []byte
+		err       error
+	)
+
+	abyteJson, err = json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.JSONToYAML(abyteJson)
+}
+
+// selectsManifest is whether one of the selectors matches the manifest.
+func selectsManifest(node map[string]any, selectors []map[string]string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+
+	for _, selector := range selectors {
+		matches := true
+
+		for dotted, want := range selector {
+			var (
+				value any = node
+			)
+
+			for _, key := range strings.Split(dotted, ".") {
+				mapValue, ok := value.(map[string]any)
+				if !ok {
+					value = nil
+					break
+				}
+				value = mapValue[key]
+			}
+
+			if value == nil || fmt.Sprint(value) != want {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyManifestRule applies the operations of a rule in order, and returns whether any changed
+// the manifest.
+func applyManifestRule(node map[string]any, rule manifestRule, vars map[string]string) (bool, error) {
+	var (
+		changed = false
+	)
+
+	for _, operation := range rule.Patch {
+		var (
+			value    any
+			segments = splitPointer(operation.Path)
+			err      error
+		)
+
+		value, err = expandVariables(operation.Value, vars)
+		if err != nil {
+			return false, err
+		}
+
+		parentSegments, last := segments[:len(segments)-1], segments[len(segments)-1]
+
+		for _, parent := range uniqueObjects(matchPointer(node, parentSegments)) {
+			var (
+				keys []string
+			)
+
+			if last == "*" {
+				for key := range parent {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+			} else {
+				keys = []string{last}
+			}
+
+			for _, key := range keys {
+				if patchKey(parent, key, operation, value) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// patchKey applies an operation to one key, and returns whether it changed anything.  Every key
+// gets its own copy of value, so that a later operation on one location does not change another.
+func patchKey(parent map[string]any, key string, operation patchOperation, value any) bool {
+	var (
+		current, exists = parent[key]
+	)
+
+	if exists && operation.IfType != "" && jsonType(current) != operation.IfType {
+		return false
+	}
+
+	switch operation.Op {
+	case "add":
+		if exists && reflect.DeepEqual(current, value) {
+			return false
+		}
+		parent[key] = copyValue(value)
+
+	case "replace":
+		if !exists || reflect.DeepEqual(current, value) {
+			return false
+		}
+		parent[key] = copyValue(value)
+
+	case "remove":
+		if !exists {
+			return false
+		}
+		delete(parent, key)
+
+	case "append":
+		text, ok := current.(string)
+		if !exists || !ok {
+			return false
+		}
+		if operation.UnlessContains != "" && strings.Contains(text, operation.UnlessContains) {
+			return false
+		}
+		parent[key] = text + fmt.Sprint(value)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// matchPointer returns every object in node which the pointer segments lead to.
+func matchPointer(node any, segments []string) []map[string]any {
+	var (
+		found []map[string]any
+	)
+
+	if len(segments) == 0 {
+		if mapNode, ok := node.(map[string]any); ok {
+			found = append(found, mapNode)
+		}
+		return found
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	if segment == "**" {
+		// Zero levels, or one more level while staying on **.
+		found = append(found, matchPointer(node, rest)...)
+		for _, child := range children(node) {
+			found = append(found, matchPointer(child, segments)...)
+		}
+		return found
+	}
+
+	switch value := node.(type) {
+	case map[string]any:
+		if segment == "*" {
+			for _, child := range children(value) {
+				found = append(found, matchPointer(child, rest)...)
+			}
+		} else if child, ok := value[segment]; ok {
+			found = append(found, matchPointer(child, rest)...)
+		}
+	case []any:
+		if segment == "*" {
+			for _, child := range value {
+				found = append(found, matchPointer(child, rest)...)
+			}
+		} else if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(value) {
+			found = append(found, matchPointer(value[index], rest)...)
+		}
+	}
+
+	return found
+}
+
+// uniqueObjects drops the objects which more than one ** path led to.
+func uniqueObjects(objects []map[string]any) []map[string]any {
+	var (
+		seen   = make(map[uintptr]bool)
+		result []map[string]any
+	)
+
+	for _, object := range objects {
+		pointer := reflect.ValueOf(object).Pointer()
+		if seen[pointer] {
+			continue
+		}
+		seen[pointer] = true
+		result = append(result, object)
+	}
+
+	return result
+}
+
+// children returns the values of an object, in key order, or the elements of an array.
+func children(node any) []any {
+	var (
+		result []any
+	)
+
+	switch value := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result = append(result, value[key])
+		}
+	case []any:
+		result = value
+	}
+
+	return result
+}
+
+// splitPointer splits a JSON Pointer and undoes its ~1 and ~0 escapes.
+func splitPointer(pointer string) []string {
+	var (
+		segments = strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	)
+
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+
+	return segments
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// copyValue returns a deep copy of a value decoded from JSON.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			result[key] = copyValue(child)
+		}
+		return result
+
+	case []any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			result[i] = copyValue(child)
+		}
+		return result
+	}
+
+	return value
+}
+
+// expandVariables returns a copy of value with ${name} replaced in every string.
+func expandVariables(value any, vars map[string]string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		var (
+			missing []string
+		)
+
+		expanded := manifestRuleVariable.ReplaceAllStringFunc(v, func(match string) string {
+			name := manifestRuleVariable.FindStringSubmatch(match)[1]
+			if replacement, ok := vars[name]; ok {
+				return replacement
+			}
+			missing = append(missing, name)
+			return match
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("unknown variable %s", strings.Join(missing, ", "))
+		}
+		return expanded, nil
+
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, child := range v {
+			expanded, err := expandVariables(child, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = expanded
+		}
+		return result, nil
+
+	case []any:
+		result := make([]any, len(v))
+		for i, child := range v {
+			expanded, err := expandVariables(child, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,428 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testManifests = map[string]string{
+	"openshift/99_openshift-cluster-api_worker-machineset-0.yaml": `apiVersion: machine.openshift.io/v1beta1
+kind: MachineSet
+metadata:
+  name: test-abc12-worker-0
+  namespace: openshift-machine-api
+spec:
+  replicas: 3
+  template:
+    spec:
+      providerSpec:
+        value:
+          flavor: worker
+          image: rhcos-old
+          securityGroups:
+          - filter: {}
+            name: test-abc12-worker
+`,
+	"openshift/99_openshift-cluster-api_master-machines-0.yaml": `apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: test-abc12-master-0
+  namespace: openshift-machine-api
+spec:
+  providerSpec:
+    value:
+      image: rhcos-old
+      securityGroups:
+      - filter: {}
+        name: test-abc12-master
+`,
+	"cluster-api/machines/10_inframachine_test-abc12-master-0.yaml": `apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+kind: OpenStackMachine
+metadata:
+  name: test-abc12-master-0
+spec:
+  flavor: master
+  image:
+    filter:
+      name: rhcos-old
+  securityGroups:
+  - filter:
+      name: test-abc12-master
+`,
+	"openshift/99_kubeadmin-password-secret.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: kubeadmin
+  namespace: kube-system
+data:
+  image: cmhjb3Mtb2xk
+`,
+	"manifests/cloud-provider-config.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cloud-provider-config
+  namespace: openshift-config
+data:
+  config: |
+    [Global]
+    secret-name = openstack-credentials
+`,
+}
+
+var testManifestVars = map[string]string{
+	"rhcosImageName": "rhcos-9.6",
+	"infraID":        "test-abc12",
+	"clusterName":    "test",
+}
+
+func writeTestManifests(t *testing.T) string {
+	var (
+		directory = t.TempDir()
+	)
+
+	for filename, content := range testManifests {
+		path := filepath.Join(directory, filename)
+
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return directory
+}
+
+// lookup follows the keys and indexes from node, and returns nil when one is missing.
+func lookup(node any, path ...any) any {
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			mapNode, ok := node.(map[string]any)
+			if !ok {
+				return nil
+			}
+			node = mapNode[key]
+		case int:
+			arrayNode, ok := node.([]any)
+			if !ok || key >= len(arrayNode) {
+				return nil
+			}
+			node = arrayNode[key]
+		}
+	}
+	return node
+}
+
+func TestDefaultManifestRules(t *testing.T) {
+	var (
+		directory = writeTestManifests(t)
+		edited    = make(map[string]map[string]any)
+	)
+
+	rules, err := loadManifestRules("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits, err := planManifestRules(directory, rules, testManifestVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRules := map[string][]string{
+		"cluster-api/machines/10_inframachine_test-abc12-master-0.yaml": {"remove-security-groups", "rhcos-image"},
+		"manifests/cloud-provider-config.yaml":                          {"disable-load-balancer"},
+		"openshift/99_openshift-cluster-api_master-machines-0.yaml":     {"remove-security-groups", "rhcos-image"},
+		"openshift/99_openshift-cluster-api_worker-machineset-0.yaml":   {"remove-security-groups", "rhcos-image"},
+	}
+	if len(edits) != len(wantRules) {
+		t.Fatalf("got %d edits, want %d", len(edits), len(wantRules))
+	}
+	for _, edit := range edits {
+		relative, err := filepath.Rel(directory, edit.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(edit.Rules, wantRules[relative]) {
+			t.Errorf("%s: got rules %v, want %v", relative, edit.Rules, wantRules[relative])
+		}
+		if string(edit.Old) != testManifests[relative] {
+			t.Errorf("%s: Old is not the file", relative)
+		}
+
+		edited[relative], err = yamlToMap(edit.New)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	machineSet := edited["openshift/99_openshift-cluster-api_worker-machineset-0.yaml"]
+	providerSpec := lookup(machineSet, "spec", "template", "spec", "providerSpec", "value")
+	if image := lookup(providerSpec, "image"); image != "rhcos-9.6" {
+		t.Errorf("MachineSet image is %v", image)
+	}
+	if securityGroups := lookup(providerSpec, "securityGroups"); securityGroups != nil {
+		t.Errorf("MachineSet still has securityGroups %v", securityGroups)
+	}
+	if flavor := lookup(providerSpec, "flavor"); flavor != "worker" {
+		t.Errorf("MachineSet flavor is %v", flavor)
+	}
+
+	machine := edited["openshift/99_openshift-cluster-api_master-machines-0.yaml"]
+	if image := lookup(machine, "spec", "providerSpec", "value", "image"); image != "rhcos-9.6" {
+		t.Errorf("Machine image is %v", image)
+	}
+
+	openStackMachine := edited["cluster-api/machines/10_inframachine_test-abc12-master-0.yaml"]
+	if image := lookup(openStackMachine, "spec", "image"); !reflect.DeepEqual(image, map[string]any{"filter": map[string]any{"name": "rhcos-9.6"}}) {
+		t.Errorf("OpenStackMachine image is %v", image)
+	}
+	if securityGroups := lookup(openStackMachine, "spec", "securityGroups"); securityGroups != nil {
+		t.Errorf("OpenStackMachine still has securityGroups %v", securityGroups)
+	}
+
+	config, _ := lookup(edited["manifests/cloud-provider-config.yaml"], "data", "config").(string)
+	if !strings.HasPrefix(config, "[Global]\n") || !strings.HasSuffix(config, "[LoadBalancer]\nenabled = false\n") {
+		t.Errorf("cloud-provider-config is %q", config)
+	}
+
+	// The rules are idempotent, so running them again on what they wrote changes nothing.
+	err = writeManifestEdits(edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits, err = planManifestRules(directory, rules, testManifestVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("the rules change their own output again: %+v", edits)
+	}
+}
+
+func TestApplyManifestRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation patchOperation
+		node      string
+		want      string
+		changed   bool
+	}{
+		{
+			name:      "* matches every key",
+			operation: patchOperation{Op: "remove", Path: "/spec/*/secret"},
+			node:      `{"spec": {"a": {"secret": 1, "keep": 2}, "b": {"secret": 3}, "c": "text"}}`,
+			want:      `{"spec": {"a": {"keep": 2}, "b": {}, "c": "text"}}`,
+			changed:   true,
+		},
+		{
+			name:      "* as the last segment",
+			operation: patchOperation{Op: "replace", Path: "/labels/*", Value: "x"},
+			node:      `{"labels": {"a": "1", "b": "2"}}`,
+			want:      `{"labels": {"a": "x", "b": "x"}}`,
+			changed:   true,
+		},
+		{
+			name:      "* matches every index",
+			operation: patchOperation{Op: "add", Path: "/items/*/ready", Value: true},
+			node:      `{"items": [{"name": "a"}, {"name": "b", "ready": true}]}`,
+			want:      `{"items": [{"name": "a", "ready": true}, {"name": "b", "ready": true}]}`,
+			changed:   true,
+		},
+		{
+			name:      "index",
+			operation: patchOperation{Op: "remove", Path: "/items/1/name"},
+			node:      `{"items": [{"name": "a"}, {"name": "b"}]}`,
+			want:      `{"items": [{"name": "a"}, {}]}`,
+			changed:   true,
+		},
+		{
+			name:      "** matches any depth, including none",
+			operation: patchOperation{Op: "remove", Path: "/**/securityGroups"},
+			node:      `{"securityGroups": [], "spec": {"list": [{"securityGroups": []}], "deep": {"er": {"securityGroups": "x"}}}}`,
+			want:      `{"spec": {"list": [{}], "deep": {"er": {}}}}`,
+			changed:   true,
+		},
+		{
+			name:      "ifType object",
+			operation: patchOperation{Op: "replace", Path: "/**/image", IfType: "object", Value: map[string]any{"name": "${rhcosImageName}"}},
+			node:      `{"a": {"image": "old"}, "b": {"image": {"id": "1234"}}}`,
+			want:      `{"a": {"image": "old"}, "b": {"image": {"name": "rhcos-9.6"}}}`,
+			changed:   true,
+		},
+		{
+			name:      "ifType string",
+			operation: patchOperation{Op: "replace", Path: "/**/image", IfType: "string", Value: "${rhcosImageName}"},
+			node:      `{"a": {"image": "old"}, "b": {"image": {"id": "1234"}}}`,
+			want:      `{"a": {"image": "rhcos-9.6"}, "b": {"image": {"id": "1234"}}}`,
+			changed:   true,
+		},
+		{
+			name:      "replace does not add",
+			operation: patchOperation{Op: "replace", Path: "/spec/image", Value: "new"},
+			node:      `{"spec": {}}`,
+			want:      `{"spec": {}}`,
+			changed:   false,
+		},
+		{
+			name:      "add the same value",
+			operation: patchOperation{Op: "add", Path: "/spec/image", Value: "${infraID}-rhcos"},
+			node:      `{"spec": {"image": "test-abc12-rhcos"}}`,
+			want:      `{"spec": {"image": "test-abc12-rhcos"}}`,
+			changed:   false,
+		},
+		{
+			name:      "append",
+			operation: patchOperation{Op: "append", Path: "/data/config", UnlessContains: "[LoadBalancer]", Value: "[LoadBalancer]\n"},
+			node:      `{"data": {"config": "[Global]\n"}}`,
+			want:      `{"data": {"config": "[Global]\n[LoadBalancer]\n"}}`,
+			changed:   true,
+		},
+		{
+			name:      "unlessContains",
+			operation: patchOperation{Op: "append", Path: "/data/config", UnlessContains: "[LoadBalancer]", Value: "[LoadBalancer]\n"},
+			node:      `{"data": {"config": "[LoadBalancer]\nenabled = true\n"}}`,
+			want:      `{"data": {"config": "[LoadBalancer]\nenabled = true\n"}}`,
+			changed:   false,
+		},
+		{
+			name:      "append to a non-string",
+			operation: patchOperation{Op: "append", Path: "/data/config", Value: "x"},
+			node:      `{"data": {"config": 1}}`,
+			want:      `{"data": {"config": 1}}`,
+			changed:   false,
+		},
+	}
+
+	for _, test := range tests {
+		node, err := yamlToMap([]byte(test.node))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := yamlToMap([]byte(test.want))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changed, err := applyManifestRule(node, manifestRule{Name: test.name, Patch: []patchOperation{test.operation}}, testManifestVars)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if changed != test.changed {
+			t.Errorf("%s: changed is %v, want %v", test.name, changed, test.changed)
+		}
+		if !reflect.DeepEqual(node, want) {
+			t.Errorf("%s: got %v, want %v", test.name, node, want)
+		}
+	}
+}
+
+func TestApplyManifestRuleCopiesValues(t *testing.T) {
+	node, err := yamlToMap([]byte(`{"a": {"image": {}}, "b": {"image": {}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := manifestRule{
+		Name: "copies",
+		Patch: []patchOperation{
+			{Op: "replace", Path: "/**/image", Value: map[string]any{"filter": map[string]any{"name": "${rhcosImageName}"}}},
+			{Op: "add", Path: "/a/image/filter/id", Value: "1234"},
+		},
+	}
+
+	_, err = applyManifestRule(node, rule, testManifestVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id := lookup(node, "a", "image", "filter", "id"); id != "1234" {
+		t.Errorf("a has image %v", lookup(node, "a", "image"))
+	}
+	if id := lookup(node, "b", "image", "filter", "id"); id != nil {
+		t.Errorf("the operation on a changed the image of b too: %v", lookup(node, "b", "image"))
+	}
+}
+
+func TestApplyManifestRuleUnknownVariable(t *testing.T) {
+	node := map[string]any{"image": "old"}
+
+	_, err := applyManifestRule(node, manifestRule{Patch: []patchOperation{{Op: "replace", Path: "/image", Value: "${imageName}"}}}, testManifestVars)
+	if err == nil || !strings.Contains(err.Error(), "imageName") {
+		t.Errorf("got error %v for an unknown variable", err)
+	}
+}
+
+func TestSelectsManifest(t *testing.T) {
+	node := map[string]any{
+		"kind":     "ConfigMap",
+		"metadata": map[string]any{"name": "cloud-provider-config", "generation": float64(2)},
+	}
+
+	tests := []struct {
+		selectors []map[string]string
+		want      bool
+	}{
+		{nil, true},
+		{[]map[string]string{{"kind": "ConfigMap"}}, true},
+		{[]map[string]string{{"kind": "Secret"}}, false},
+		{[]map[string]string{{"kind": "ConfigMap", "metadata.name": "cloud-provider-config"}}, true},
+		{[]map[string]string{{"kind": "ConfigMap", "metadata.name": "other"}}, false},
+		{[]map[string]string{{"kind": "Secret"}, {"metadata.name": "cloud-provider-config"}}, true},
+		{[]map[string]string{{"metadata.generation": "2"}}, true},
+		{[]map[string]string{{"metadata.name.first": "cloud-provider-config"}}, false},
+		{[]map[string]string{{"spec.missing": ""}}, false},
+	}
+
+	for _, test := range tests {
+		if got := selectsManifest(node, test.selectors); got != test.want {
+			t.Errorf("selectsManifest(%v) is %v, want %v", test.selectors, got, test.want)
+		}
+	}
+}
+
+func TestLoadManifestRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"no files":    "rules:\n- name: r\n  patch:\n  - op: remove\n    path: /a\n",
+		"unknown op":  "rules:\n- name: r\n  files: [a]\n  patch:\n  - op: move\n    path: /a\n",
+		"bad path":    "rules:\n- name: r\n  files: [a]\n  patch:\n  - op: remove\n    path: a\n",
+		"unknown key": "rules:\n- name: r\n  files: [a]\n  patches: []\n",
+		"not a rule":  "rules: 1\n",
+	}
+
+	for name, content := range tests {
+		filename := filepath.Join(t.TempDir(), "rules.yaml")
+
+		err := os.WriteFile(filename, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = loadManifestRules(filename)
+		if err == nil {
+			t.Errorf("%s: loadManifestRules succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	metricsNamespace = "powervc_tool"
+)
+
+// The series served on /metrics by watch-installation.
+var (
+	watchLoopIterations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "watch",
+		Name:      "loop_iterations_total",
+		Help:      "The number of times the watch loop woke up, and whether it reconciled.",
+	}, []string{"reconciled"})
+
+	watchLoopDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "watch",
+		Name:      "loop_duration_seconds",
+		Help:      "How long an iteration of the watch loop took, without the sleep.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"reconciled"})
+
+	watchClusterServers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "watch",
+		Name:      "cluster_servers",
+		Help:      "The number of servers known for each cluster.",
+	}, []string{"cluster", "infra_id"})
+
+	dnsRecordOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "dns",
+		Name:      "record_operations_total",
+		Help:      "The number of DNS records created or deleted.",
+	}, []string{"type", "operation"})
+
+	dnsRecordFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "dns",
+		Name:      "record_failures_total",
+		Help:      "The number of DNS record lookups, creates or deletes which failed.",
+	}, []string{"type", "operation"})
+
+	haproxyPushes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "haproxy",
+		Name:      "config_pushes_total",
+		Help:      "The number of haproxy.cfg files copied to a bastion.",
+	}, []string{"cluster", "result"})
+
+	haproxyRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "haproxy",
+		Name:      "restarts_total",
+		Help:      "The number of times HAProxy was restarted on a bastion.",
+	}, []string{"cluster", "result"})
+
+	dhcpdReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "dhcpd",
+		Name:      "reloads_total",
+		Help:      "The number of times dhcpd was restarted with a new dhcpd.conf.",
+	}, []string{"result"})
+
+	openstackRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "openstack",
+		Name:      "request_duration_seconds",
+		Help:      "How long each attempt of an OpenStack API call took.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	openstackRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "openstack",
+		Name:      "retries_total",
+		Help:      "The number of times an OpenStack API call was tried again by its backoff.",
+	}, []string{"operation"})
+
+	commandConnections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "commands",
+		Name:      "connections_total",
+		Help:      "The number of connections to the port 8080 listener, and whether the TLS handshake succeeded.",
+	}, []string{"result"})
+)
+
+func metricsResult(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// observeBackoff wraps the condition of a wait.ExponentialBackoffWithContext call to time every
+// attempt and to count every attempt after the first as a retry.
+func observeBackoff(operation string, condition wait.ConditionWithContextFunc) wait.ConditionWithContextFunc {
+	var (
+		attempts = 0
+	)
+
+	return func(ctx context.Context) (bool, error) {
+		attempts++
+		if attempts > 1 {
+			openstackRetries.WithLabelValues(operation).Inc()
+		}
+
+		start := time.Now()
+		done, err := condition(ctx)
+		openstackRequestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+		return done, err
+	}
+}
+
+// observeLoopIteration records one pass of the watch loop which started at start.
+func observeLoopIteration(start time.Time, reconciled bool) {
+	var (
+		label = "false"
+	)
+
+	if reconciled {
+		label = "true"
+	}
+
+	watchLoopIterations.WithLabelValues(label).Inc()
+	watchLoopDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+}
+
+// setClusterServers replaces the per cluster server counts so deleted clusters disappear.
+func setClusterServers(bastionInformations []bastionInformation) {
+	watchClusterServers.Reset()
+
+	for _, bastionInformation := range bastionInformations {
+		if !bastionInformation.Valid {
+			continue
+		}
+
+		watchClusterServers.WithLabelValues(bastionInformation.ClusterName, bastionInformation.InfraID).Set(float64(bastionInformation.NumVMs))
+	}
+}
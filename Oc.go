@@ -15,15 +15,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	OcName = "OpenShiftCluster"
 )
 
+const (
+	// The ClusterVersion condition which openshift/api has no constant for
+	clusterVersionFailing configv1.ClusterStatusConditionType = "Failing"
+
+	nodeRolePrefix = "node-role.kubernetes.io/"
+)
+
 type Oc struct {
 	services *Services
+	clients  *kubeClients
 }
 
 func NewOc(services *Services) ([]RunnableObject, []error) {
@@ -51,15 +70,25 @@ func NewOcAlt(services *Services) ([]*Oc, []error) {
 
 func innerNewOc(services *Services) ([]*Oc, []error) {
 	var (
-		ocs  []*Oc
-		errs []error
+		ocs     []*Oc
+		errs    []error
+		clients *kubeClients
+		err     error
 	)
 
 	ocs = make([]*Oc, 1)
 	errs = make([]error, 1)
 
+	clients, err = newKubeClients(services.GetKubeConfig())
+	if err != nil {
+		// Do not return a nil object which would be asked for its status
+		errs[0] = err
+		return nil, errs
+	}
+
 	ocs[0] = &Oc{
 		services: services,
+		clients:  clients,
 	}
 
 	return ocs, errs
@@ -78,65 +107,429 @@ func (oc *Oc) Run() error {
 	return nil
 }
 
-func (oc *Oc) ClusterStatus() {
+// ClusterStatus asks the API server of the cluster about the resources which show how far the
+// installation got.  A resource which could not be listed is a warning, but if none of them could
+// be listed then the cluster was not looked at.
+func (oc *Oc) ClusterStatus(ctx context.Context) CheckResult {
 	var (
-		cmds       = []string{
-			"oc --request-timeout=5s get clusterversion",
-			"oc --request-timeout=5s get co",
-			"oc --request-timeout=5s get nodes -o=wide",
-			"oc --request-timeout=5s get pods -n openshift-machine-api",
-			"oc --request-timeout=5s get machines.machine.openshift.io -n openshift-machine-api",
-			"oc --request-timeout=5s get machineset.machine.openshift.io -n openshift-machine-api",
-			"oc --request-timeout=5s logs -l k8s-app=controller -c machine-controller -n openshift-machine-api",
-			"oc --request-timeout=5s describe co/cloud-controller-manager",
-			"oc --request-timeout=5s describe cm/cloud-provider-config -n openshift-config",
-//			"oc --request-timeout=5s get pod -l k8s-app=cloud-manager-operator -n openshift-cloud-controller-manager-operator",
-			"oc --request-timeout=5s get pods -n openshift-cloud-controller-manager-operator",
-//			"oc --request-timeout=5s describe pod -l k8s-app=openstack-cloud-controller-manager -n openshift-cloud-controller-manager",
-			"oc --request-timeout=5s get events -n openshift-cloud-controller-manager",
-			"oc --request-timeout=5s -n openshift-cloud-controller-manager-operator logs deployment/cluster-cloud-controller-manager-operator -c cluster-cloud-controller-manager",
-			"oc --request-timeout=5s get co/network",
-			"oc --request-timeout=5s get co/kube-controller-manager",
-			"oc --request-timeout=5s get co/etcd",
-			"oc --request-timeout=5s get machines.machine.openshift.io -n openshift-machine-api",
-			"oc --request-timeout=5s get machineset.m -n openshift-machine-api",
-			"oc --request-timeout=5s get pods -n openshift-machine-api",
-			"oc --request-timeout=5s get pods -n openshift-kube-controller-manager",
-			"oc --request-timeout=5s get pods -n openshift-ovn-kubernetes",
-			"oc --request-timeout=5s describe co/machine-config",
-			// oc logs kube-controller-manager-rdr-hamzy-openstack-nxqfq-master-0 -n openshift-kube-controller-manager -c kube-controller-manager | grep 'context deadline exceeded'
-		}
-		pipeCmds   = [][]string{
-			{
-				"oc --request-timeout=5s get pods -A -o=wide",
-				"sed -e /\\(Running\\|Completed\\)/d",
-			},
-			{
-				"oc --request-timeout=5s get csr",
-				"grep Pending",
-			},
-		}
-		kubeConfig string
-		err        error
+		cancel   context.CancelFunc
+		checks   = []struct {
+			what  string
+			check func(context.Context) ([]Finding, error)
+		}{
+			{"the cluster version", oc.clusterVersionFindings},
+			{"the cluster operators", oc.clusterOperatorFindings},
+			{"the nodes", oc.nodeFindings},
+			{"the machines", oc.machineFindings},
+			{"the machine sets", oc.machineSetFindings},
+			{"the certificate signing requests", oc.csrFindings},
+			{"the pods", oc.podFindings},
+		}
+		failed   = 0
+		findings []Finding
+		result   CheckResult
 	)
 
-	kubeConfig = oc.services.GetKubeConfig()
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
 
-	for _, cmd := range cmds {
-		err = runCommand(kubeConfig, cmd)
+	for _, c := range checks {
+		checkFindings, err := c.check(ctx)
 		if err != nil {
-			fmt.Printf("Error: could not run command: %v\n", err)
+			log.Debugf("ClusterStatus: %s: %v", c.what, err)
+			failed++
+			findings = append(findings, newFinding(OcName, SeverityWarning, "Could not get %s: %v", c.what, err))
+			continue
 		}
+
+		findings = append(findings, checkFindings...)
 	}
 
-	for _, twoCmds := range pipeCmds {
-		err = runTwoCommands(kubeConfig, twoCmds[0], twoCmds[1])
-		if err != nil {
-			fmt.Printf("Error: could not run command: %v\n", err)
+	result = newCheckResult(findings)
+	if failed == len(checks) {
+		result.Status = CheckUnknown
+	}
+
+	return result
+}
+
+// conditionStatus is the status of the condition of type conditionType, or Unknown when the
+// operator has not set it yet.
+func conditionStatus(conditions []configv1.ClusterOperatorStatusCondition, conditionType configv1.ClusterStatusConditionType) (configv1.ConditionStatus, string) {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status, condition.Message
+		}
+	}
+	return configv1.ConditionUnknown, ""
+}
+
+func (oc *Oc) clusterVersionFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		clusterVersion *configv1.ClusterVersion
+		finding        Finding
+		err            error
+	)
+
+	clusterVersion, err = oc.clients.getClusterVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	available, availableMessage := conditionStatus(clusterVersion.Status.Conditions, configv1.OperatorAvailable)
+	progressing, progressingMessage := conditionStatus(clusterVersion.Status.Conditions, configv1.OperatorProgressing)
+	failing, failingMessage := conditionStatus(clusterVersion.Status.Conditions, clusterVersionFailing)
+
+	switch {
+	case failing == configv1.ConditionTrue:
+		finding = newFinding("clusterversion", SeverityError, "Cluster version %s is failing: %s", clusterVersion.Status.Desired.Version, failingMessage)
+	case available != configv1.ConditionTrue:
+		finding = newFinding("clusterversion", SeverityError, "Cluster version %s is not available: %s", clusterVersion.Status.Desired.Version, availableMessage)
+	case progressing == configv1.ConditionTrue:
+		finding = newFinding("clusterversion", SeverityWarning, "Cluster version %s is progressing: %s", clusterVersion.Status.Desired.Version, progressingMessage)
+	default:
+		finding = newFinding("clusterversion", SeverityInfo, "Cluster version %s is available", clusterVersion.Status.Desired.Version)
+	}
+	finding.Fields = map[string]string{
+		"version":     clusterVersion.Status.Desired.Version,
+		"available":   string(available),
+		"progressing": string(progressing),
+		"failing":     string(failing),
+	}
+
+	return []Finding{finding}, nil
+}
+
+func (oc *Oc) clusterOperatorFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		clusterOperators []configv1.ClusterOperator
+		findings         []Finding
+		err              error
+	)
+
+	clusterOperators, err = oc.clients.listClusterOperators(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(clusterOperators, func(i, j int) bool {
+		return clusterOperators[i].Name < clusterOperators[j].Name
+	})
+
+	for _, clusterOperator := range clusterOperators {
+		var (
+			name    = "co/" + clusterOperator.Name
+			finding Finding
+		)
+
+		available, availableMessage := conditionStatus(clusterOperator.Status.Conditions, configv1.OperatorAvailable)
+		progressing, progressingMessage := conditionStatus(clusterOperator.Status.Conditions, configv1.OperatorProgressing)
+		degraded, degradedMessage := conditionStatus(clusterOperator.Status.Conditions, configv1.OperatorDegraded)
+
+		switch {
+		case degraded == configv1.ConditionTrue:
+			finding = newFinding(name, SeverityError, "Cluster operator %s is degraded: %s", clusterOperator.Name, degradedMessage)
+		case available != configv1.ConditionTrue:
+			finding = newFinding(name, SeverityError, "Cluster operator %s is not available: %s", clusterOperator.Name, availableMessage)
+		case progressing == configv1.ConditionTrue:
+			finding = newFinding(name, SeverityWarning, "Cluster operator %s is progressing: %s", clusterOperator.Name, progressingMessage)
+		default:
+			finding = newFinding(name, SeverityInfo, "Cluster operator %s is available", clusterOperator.Name)
 		}
+		finding.Fields = map[string]string{
+			"available":   string(available),
+			"progressing": string(progressing),
+			"degraded":    string(degraded),
+		}
+		findings = append(findings, finding)
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding("co", SeverityError, "No cluster operators found"))
 	}
+
+	return findings, nil
+}
+
+// nodeRoles is the roles from the node-role.kubernetes.io/ labels, the same as oc get nodes.
+func nodeRoles(node corev1.Node) string {
+	var (
+		roles []string
+	)
+
+	for label := range node.Labels {
+		if role, ok := strings.CutPrefix(label, nodeRolePrefix); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	if len(roles) == 0 {
+		return "<none>"
+	}
+	return strings.Join(roles, ",")
+}
+
+func (oc *Oc) nodeFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		nodes    *corev1.NodeList
+		findings []Finding
+		err      error
+	)
+
+	nodes, err = oc.clients.kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nodes.Items, func(i, j int) bool {
+		return nodes.Items[i].Name < nodes.Items[j].Name
+	})
+
+	for _, node := range nodes.Items {
+		var (
+			name    = "node/" + node.Name
+			ready   = corev1.ConditionUnknown
+			message string
+			finding Finding
+		)
+
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				ready = condition.Status
+				message = condition.Message
+			}
+		}
+
+		if ready == corev1.ConditionTrue {
+			finding = newFinding(name, SeverityInfo, "Node %s (%s) is ready, kubelet %s", node.Name, nodeRoles(node), node.Status.NodeInfo.KubeletVersion)
+		} else {
+			finding = newFinding(name, SeverityError, "Node %s (%s) is not ready: %s", node.Name, nodeRoles(node), message)
+		}
+		finding.Fields = map[string]string{
+			"ready": string(ready),
+			"roles": nodeRoles(node),
+		}
+		findings = append(findings, finding)
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding("node", SeverityError, "No nodes found"))
+	}
+
+	return findings, nil
+}
+
+func (oc *Oc) machineFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		machines []machinev1beta1.Machine
+		findings []Finding
+		err      error
+	)
+
+	machines, err = oc.clients.listMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+
+	for _, machine := range machines {
+		var (
+			name     = "machine/" + machine.Name
+			phase    = "<none>"
+			nodeName = "<none>"
+			finding  Finding
+		)
+
+		if machine.Status.Phase != nil {
+			phase = *machine.Status.Phase
+		}
+		if machine.Status.NodeRef != nil {
+			nodeName = machine.Status.NodeRef.Name
+		}
+
+		switch {
+		case phase == "Failed" || machine.Status.ErrorMessage != nil:
+			var errorMessage string
+			if machine.Status.ErrorMessage != nil {
+				errorMessage = *machine.Status.ErrorMessage
+			}
+			finding = newFinding(name, SeverityError, "Machine %s is %s: %s", machine.Name, phase, errorMessage)
+		case phase != "Running":
+			finding = newFinding(name, SeverityWarning, "Machine %s is %s", machine.Name, phase)
+		default:
+			finding = newFinding(name, SeverityInfo, "Machine %s is %s as node %s", machine.Name, phase, nodeName)
+		}
+		finding.Fields = map[string]string{
+			"phase": phase,
+			"node":  nodeName,
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+func (oc *Oc) machineSetFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		machineSets []machinev1beta1.MachineSet
+		findings    []Finding
+		err         error
+	)
+
+	machineSets, err = oc.clients.listMachineSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(machineSets, func(i, j int) bool {
+		return machineSets[i].Name < machineSets[j].Name
+	})
+
+	for _, machineSet := range machineSets {
+		var (
+			name     = "machineset/" + machineSet.Name
+			replicas = int32(1)
+			finding  Finding
+		)
+
+		if machineSet.Spec.Replicas != nil {
+			replicas = *machineSet.Spec.Replicas
+		}
+
+		if machineSet.Status.ReadyReplicas != replicas {
+			finding = newFinding(name, SeverityWarning, "Machine set %s has %d of %d replicas ready", machineSet.Name, machineSet.Status.ReadyReplicas, replicas)
+		} else {
+			finding = newFinding(name, SeverityInfo, "Machine set %s has all %d replicas ready", machineSet.Name, replicas)
+		}
+		finding.Fields = map[string]string{
+			"replicas": strconv.Itoa(int(replicas)),
+			"ready":    strconv.Itoa(int(machineSet.Status.ReadyReplicas)),
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// csrFindings warns about every certificate signing request which was neither approved nor
+// denied.  A node cannot join the cluster until its requests are approved.
+func (oc *Oc) csrFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		csrs     *certificatesv1.CertificateSigningRequestList
+		findings []Finding
+		err      error
+	)
+
+	csrs, err = oc.clients.kube.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, csr := range csrs.Items {
+		var (
+			pending = true
+			finding Finding
+		)
+
+		for _, condition := range csr.Status.Conditions {
+			if condition.Type == certificatesv1.CertificateApproved || condition.Type == certificatesv1.CertificateDenied {
+				pending = false
+			}
+		}
+		if !pending {
+			continue
+		}
+
+		finding = newFinding("csr/"+csr.Name, SeverityWarning, "Certificate signing request %s from %s is pending", csr.Name, csr.Spec.Username)
+		finding.Fields = map[string]string{"state": "Pending"}
+		findings = append(findings, finding)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Name < findings[j].Name
+	})
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding("csr", SeverityInfo, "No pending certificate signing requests out of %d", len(csrs.Items)))
+	}
+
+	return findings, nil
+}
+
+// podProblem is why a pod is not healthy, the same as the STATUS column of oc get pods.
+func podProblem(pod corev1.Pod) (Severity, string) {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return SeverityInfo, ""
+	case corev1.PodFailed:
+		return SeverityError, string(pod.Status.Phase)
+	}
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting == nil {
+			continue
+		}
+
+		switch containerStatus.State.Waiting.Reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "CreateContainerConfigError", "CreateContainerError":
+			return SeverityError, containerStatus.State.Waiting.Reason
+		}
+	}
+
+	if pod.Status.Phase != corev1.PodRunning {
+		return SeverityWarning, string(pod.Status.Phase)
+	}
+
+	return SeverityInfo, ""
+}
+
+func (oc *Oc) podFindings(ctx context.Context) ([]Finding, error) {
+	var (
+		pods     *corev1.PodList
+		findings []Finding
+		err      error
+	)
+
+	pods, err = oc.clients.kube.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pod := range pods.Items {
+		severity, problem := podProblem(pod)
+		if problem == "" {
+			continue
+		}
+
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			nodeName = "<none>"
+		}
+
+		finding := newFinding(fmt.Sprintf("pod/%s/%s", pod.Namespace, pod.Name), severity, "Pod %s in %s is %s on node %s", pod.Name, pod.Namespace, problem, nodeName)
+		finding.Fields = map[string]string{"status": problem}
+		findings = append(findings, finding)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Name < findings[j].Name
+	})
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding("pod", SeverityInfo, "All %d pods are running or completed", len(pods.Items)))
+	}
+
+	return findings, nil
 }
 
 func (oc *Oc) Priority() (int, error) {
 	return -1, nil
 }
+
+func (oc *Oc) Dependencies() []string {
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"strings"
 	"time"
 
@@ -25,9 +26,14 @@ import (
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/subnets"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/availabilityzones"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/keypairs"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/containers"
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/objects"
 	"github.com/gophercloud/gophercloud/v2/pagination"
 
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -41,7 +47,7 @@ func getServiceClient(ctx context.Context, serviceType string, cloud string) (cl
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getServiceClient", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -54,7 +60,7 @@ func getServiceClient(ctx context.Context, serviceType string, cloud string) (cl
 		}
 
 		return true, nil
-	})
+	}))
 
 	return
 }
@@ -80,7 +86,7 @@ func findFlavor(ctx context.Context, cloudName string, name string) (foundFlavor
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findFlavor", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -99,7 +105,7 @@ func findFlavor(ctx context.Context, cloudName string, name string) (foundFlavor
 //		log.Debugf("findFlavor: allFlavors = %+v", allFlavors)
 
 		return true, nil
-	})
+	}))
 	if err != nil {
 		return
 	}
@@ -138,7 +144,7 @@ func findImage(ctx context.Context, cloudName string, name string) (foundImage i
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findImage", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -157,7 +163,7 @@ func findImage(ctx context.Context, cloudName string, name string) (foundImage i
 //		log.Debugf("findImage: allImages = %+v", allImages)
 
 		return true, nil
-	})
+	}))
 	if err != nil {
 		return
 	}
@@ -196,7 +202,7 @@ func findNetwork(ctx context.Context, cloudName string, name string) (foundNetwo
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findNetwork", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -215,7 +221,7 @@ func findNetwork(ctx context.Context, cloudName string, name string) (foundNetwo
 //		log.Debugf("findNetwork: allNetworks = %+v", allNetworks)
 
 		return true, nil
-	})
+	}))
 	if err != nil {
 		return
 	}
@@ -233,6 +239,96 @@ func findNetwork(ctx context.Context, cloudName string, name string) (foundNetwo
 	return
 }
 
+func getSubnet(ctx context.Context, cloudName string, id string) (foundSubnet *subnets.Subnet, err error) {
+	connNetwork, err := getServiceClient(ctx, "network", cloudName)
+	if err != nil {
+		err = fmt.Errorf("getSubnet: getServiceClient returns %v", err)
+		return
+	}
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getSubnet", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("getSubnet: duration = %v, calling subnets.Get", leftInContext(ctx))
+		foundSubnet, err2 = subnets.Get(ctx, connNetwork, id).Extract()
+		if gophercloud.ResponseCodeIs(err2, http.StatusNotFound) {
+			return true, err2
+		}
+		if err2 != nil {
+			log.Debugf("getSubnet: subnets.Get returned error %v", err2)
+			return false, nil
+		}
+
+		return true, nil
+	}))
+
+	return
+}
+
+func findAvailabilityZone(ctx context.Context, cloudName string, name string) (foundZone availabilityzones.AvailabilityZone, err error) {
+	var (
+		pager    pagination.Page
+		allZones []availabilityzones.AvailabilityZone
+		zone     availabilityzones.AvailabilityZone
+	)
+
+	connCompute, err := getServiceClient(ctx, "compute", cloudName)
+	if err != nil {
+		err = fmt.Errorf("findAvailabilityZone: getServiceClient returns %v", err)
+		return
+	}
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findAvailabilityZone", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("findAvailabilityZone: duration = %v, calling availabilityzones.List", leftInContext(ctx))
+		pager, err2 = availabilityzones.List(connCompute).AllPages(ctx)
+		if err2 != nil {
+			return false, nil
+		}
+
+		allZones, err2 = availabilityzones.ExtractAvailabilityZones(pager)
+		if err2 != nil {
+			return false, nil
+		}
+
+		return true, nil
+	}))
+	if err != nil {
+		return
+	}
+
+	for _, zone = range allZones {
+		log.Debugf("findAvailabilityZone: zone.ZoneName = %s, zone.ZoneState.Available = %v", zone.ZoneName, zone.ZoneState.Available)
+
+		if zone.ZoneName == name {
+			foundZone = zone
+			return
+		}
+	}
+
+	err = fmt.Errorf("Could not find availability zone named %s", name)
+	return
+}
+
 func findServer(ctx context.Context, cloudName string, name string) (foundServer servers.Server, err error) {
 	var (
 		allServers []servers.Server
@@ -271,7 +367,7 @@ func waitForServer(ctx context.Context, cloudName string, name string) error {
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("waitForServer", func(context.Context) (bool, error) {
 		var (
 			foundServer servers.Server
 			err2        error
@@ -295,7 +391,7 @@ func waitForServer(ctx context.Context, cloudName string, name string) error {
 			return true, nil
 		}
 		return false, nil
-	})
+	}))
 	if err != nil {
 		return err
 	}
@@ -324,7 +420,7 @@ func getAllServers(ctx context.Context, cloud string) (allServers []servers.Serv
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getAllServers", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -349,7 +445,7 @@ func getAllServers(ctx context.Context, cloud string) (allServers []servers.Serv
 //		log.Debugf("getAllServers: allServers = %+v", allServers)
 
 		return true, nil
-	})
+	}))
 
 	return
 }
@@ -391,7 +487,7 @@ func findKeyPair(ctx context.Context, cloudName string, name string) (foundKeyPa
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findKeyPair", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -410,7 +506,7 @@ func findKeyPair(ctx context.Context, cloudName string, name string) (foundKeyPa
 //		log.Debugf("findKeyPair: allKeyPairs = %+v", allKeyPairs)
 
 		return true, nil
-	})
+	}))
 	if err != nil {
 		return
 	}
@@ -449,7 +545,7 @@ func findHypervisor(ctx context.Context, cloudName string, name string) (foundHy
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findHypervisor", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -468,7 +564,7 @@ func findHypervisor(ctx context.Context, cloudName string, name string) (foundHy
 //		log.Debugf("findHypervisor: allHypervisors = %+v", allHypervisors)
 
 		return true, nil
-	})
+	}))
 	if err != nil {
 		return
 	}
@@ -499,7 +595,7 @@ func getAllHypervisors(ctx context.Context, connCompute *gophercloud.ServiceClie
 		Steps:    math.MaxInt32,
 	}
 
-	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getAllHypervisors", func(context.Context) (bool, error) {
 		var (
 			err2 error
 		)
@@ -526,7 +622,36 @@ func getAllHypervisors(ctx context.Context, connCompute *gophercloud.ServiceClie
 //		log.Debugf("getAllHypervisors: allHypervisors = %+v", allHypervisors)
 
 		return true, nil
-	})
+	}))
+
+	return
+}
+
+func getFlavor(ctx context.Context, connCompute *gophercloud.ServiceClient, id string) (foundFlavor *flavors.Flavor, err error) {
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getFlavor", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("getFlavor: duration = %v, calling flavors.Get", leftInContext(ctx))
+		foundFlavor, err2 = flavors.Get(ctx, connCompute, id).Extract()
+		if gophercloud.ResponseCodeIs(err2, http.StatusNotFound) {
+			return true, err2
+		}
+		if err2 != nil {
+			log.Debugf("getFlavor: flavors.Get returned error %v", err2)
+			return false, nil
+		}
+
+		return true, nil
+	}))
 
 	return
 }
@@ -546,3 +671,251 @@ func findHypervisorverInList(allHypervisors []hypervisors.Hypervisor, name strin
 	err = fmt.Errorf("Could not find hypervisor named %s", name)
 	return
 }
+
+func findPorts(ctx context.Context, cloudName string, name string) (foundPorts []ports.Port, err error) {
+	var (
+		pager pagination.Page
+	)
+
+	connNetwork, err := getServiceClient(ctx, "network", cloudName)
+	if err != nil {
+		err = fmt.Errorf("findPorts: getServiceClient returns %v", err)
+		return
+	}
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("findPorts", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("findPorts: duration = %v, calling ports.List", leftInContext(ctx))
+		pager, err2 = ports.List(connNetwork, ports.ListOpts{Name: name}).AllPages(ctx)
+		if err2 != nil {
+			return false, nil
+		}
+
+		foundPorts, err2 = ports.ExtractPorts(pager)
+		if err2 != nil {
+			return false, nil
+		}
+
+		return true, nil
+	}))
+
+	return
+}
+
+func deletePort(ctx context.Context, cloudName string, portID string) error {
+	var (
+		err error
+	)
+
+	connNetwork, err := getServiceClient(ctx, "network", cloudName)
+	if err != nil {
+		return fmt.Errorf("deletePort: getServiceClient returns %v", err)
+	}
+
+	err = ports.Delete(ctx, connNetwork, portID).ExtractErr()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		// Already gone
+		return nil
+	}
+
+	return err
+}
+
+func deleteServer(ctx context.Context, cloudName string, server servers.Server) error {
+	var (
+		err error
+	)
+
+	connCompute, err := getServiceClient(ctx, "compute", cloudName)
+	if err != nil {
+		return fmt.Errorf("deleteServer: getServiceClient returns %v", err)
+	}
+
+	err = servers.Delete(ctx, connCompute, server.ID).ExtractErr()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		// Already gone
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return waitForServerDeleted(ctx, cloudName, server.Name)
+}
+
+func waitForServerDeleted(ctx context.Context, cloudName string, name string) error {
+	backoff := wait.Backoff{
+		Duration: 15 * time.Second,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	return wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("waitForServerDeleted", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		_, err2 = findServer(ctx, cloudName, name)
+		if err2 != nil {
+			if strings.HasPrefix(err2.Error(), "Could not find server named") {
+				log.Debugf("waitForServerDeleted: server %s is gone", name)
+				return true, nil
+			}
+
+			log.Debugf("waitForServerDeleted: findServer returned %v", err2)
+			return false, nil
+		}
+
+		log.Debugf("waitForServerDeleted: server %s still exists", name)
+		return false, nil
+	}))
+}
+
+func findContainer(ctx context.Context, cloudName string, containerName string) (found bool, err error) {
+	var (
+		connObjectStore *gophercloud.ServiceClient
+	)
+
+	connObjectStore, err = getServiceClient(ctx, "object-store", cloudName)
+	if err != nil {
+		err = fmt.Errorf("findContainer: getServiceClient returns %v", err)
+		return
+	}
+
+	_, err = containers.Get(ctx, connObjectStore, containerName, nil).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	found = true
+	return
+}
+
+// deleteContainer removes every object inside of a Swift container and then the container itself.
+func deleteContainer(ctx context.Context, cloudName string, containerName string) error {
+	var (
+		connObjectStore *gophercloud.ServiceClient
+		pager           pagination.Page
+		objectNames     []string
+		err             error
+	)
+
+	connObjectStore, err = getServiceClient(ctx, "object-store", cloudName)
+	if err != nil {
+		return fmt.Errorf("deleteContainer: getServiceClient returns %v", err)
+	}
+
+	pager, err = objects.List(connObjectStore, containerName, objects.ListOpts{}).AllPages(ctx)
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		// Already gone
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("deleteContainer: objects.List returns %v", err)
+	}
+
+	objectNames, err = objects.ExtractNames(pager)
+	if err != nil {
+		return fmt.Errorf("deleteContainer: objects.ExtractNames returns %v", err)
+	}
+
+	for _, objectName := range objectNames {
+		log.Debugf("deleteContainer: deleting object %s/%s", containerName, objectName)
+
+		_, err = objects.Delete(ctx, connObjectStore, containerName, objectName, nil).Extract()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return fmt.Errorf("deleteContainer: objects.Delete(%s) returns %v", objectName, err)
+		}
+	}
+
+	log.Debugf("deleteContainer: deleting container %s", containerName)
+	_, err = containers.Delete(ctx, connObjectStore, containerName).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func getAllContainers(ctx context.Context, cloudName string) (allContainers []containers.Container, err error) {
+	var (
+		connObjectStore *gophercloud.ServiceClient
+		pager           pagination.Page
+	)
+
+	connObjectStore, err = getServiceClient(ctx, "object-store", cloudName)
+	if err != nil {
+		err = fmt.Errorf("getAllContainers: getServiceClient returns %v", err)
+		return
+	}
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getAllContainers", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("getAllContainers: duration = %v, calling containers.List", leftInContext(ctx))
+		pager, err2 = containers.List(connObjectStore, containers.ListOpts{}).AllPages(ctx)
+		if err2 != nil {
+			log.Debugf("getAllContainers: containers.List returned error %v", err2)
+			return false, nil
+		}
+
+		allContainers, err2 = containers.ExtractInfo(pager)
+		if err2 != nil {
+			log.Debugf("getAllContainers: containers.ExtractInfo returned error %v", err2)
+			return false, nil
+		}
+
+		return true, nil
+	}))
+
+	return
+}
+
+// getContainerCreated returns when a Swift container was created, from its X-Timestamp header.
+func getContainerCreated(ctx context.Context, cloudName string, containerName string) (created time.Time, err error) {
+	var (
+		connObjectStore *gophercloud.ServiceClient
+		header          *containers.GetHeader
+	)
+
+	connObjectStore, err = getServiceClient(ctx, "object-store", cloudName)
+	if err != nil {
+		err = fmt.Errorf("getContainerCreated: getServiceClient returns %v", err)
+		return
+	}
+
+	header, err = containers.Get(ctx, connObjectStore, containerName, nil).Extract()
+	if err != nil {
+		return
+	}
+	log.Debugf("getContainerCreated: %s X-Timestamp = %f", containerName, header.Timestamp)
+
+	seconds, fraction := math.Modf(header.Timestamp)
+	created = time.Unix(int64(seconds), int64(fraction*1e9))
+
+	return
+}
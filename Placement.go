@@ -0,0 +1,201 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+// hostUsage is what the cluster has on one hypervisor.
+type hostUsage struct {
+	masters []string
+	workers []string
+	others  []string
+}
+
+// clusterFlavor is the flavor another VM of the cluster would be created with.  That is the
+// flavor of the workers, or of the masters when there are no workers yet.  Nova only returns
+// the flavor ID before microversion 2.47, and only the flavor's original name from then on.
+func clusterFlavor(ctx context.Context, cloudName string, connCompute *gophercloud.ServiceClient, clusterServers []servers.Server) (*flavors.Flavor, error) {
+	var (
+		flavorID   string
+		flavorName string
+	)
+
+	for _, role := range []string{"worker", "master"} {
+		for _, server := range clusterServers {
+			if !strings.Contains(strings.ToLower(server.Name), role) {
+				continue
+			}
+
+			if id, ok := server.Flavor["id"].(string); ok && id != "" {
+				flavorID = id
+				break
+			}
+			if name, ok := server.Flavor["original_name"].(string); ok && name != "" {
+				flavorName = name
+				break
+			}
+		}
+		if flavorID != "" || flavorName != "" {
+			break
+		}
+	}
+
+	switch {
+	case flavorID != "":
+		log.Debugf("clusterFlavor: flavorID = %s", flavorID)
+
+		return getFlavor(ctx, connCompute, flavorID)
+	case flavorName != "":
+		log.Debugf("clusterFlavor: flavorName = %s", flavorName)
+
+		flavor, err := findFlavor(ctx, cloudName, flavorName)
+		if err != nil {
+			return nil, err
+		}
+		return &flavor, nil
+	}
+
+	return nil, fmt.Errorf("Error: No master or worker has a flavor")
+}
+
+// flavorFits is whether a VM of flavor can still be created on hypervisor.  Disk is not counted
+// when the hypervisor does not report local storage, since the volumes are then on a SAN.
+func flavorFits(hypervisor hypervisors.Hypervisor, flavor *flavors.Flavor) bool {
+	if hypervisor.State != "up" || hypervisor.Status != "enabled" {
+		return false
+	}
+	if hypervisor.VCPUs-hypervisor.VCPUsUsed < flavor.VCPUs {
+		return false
+	}
+	if hypervisor.MemoryMB-hypervisor.MemoryMBUsed < flavor.RAM {
+		return false
+	}
+	if hypervisor.LocalGB > 0 && hypervisor.LocalGB-hypervisor.LocalGBUsed < flavor.Disk {
+		return false
+	}
+	return true
+}
+
+// placementFindings reports every hypervisor with its free capacity and the cluster's VMs on it.
+// Two masters on the same hypervisor is an error, since losing that host loses etcd quorum.  A
+// nil flavor skips whether another VM fits.
+func placementFindings(clusterServers []servers.Server, allHypervisors []hypervisors.Hypervisor, flavor *flavors.Flavor) []Finding {
+	var (
+		usages   = make(map[string]*hostUsage)
+		anyFits  = false
+		findings []Finding
+	)
+
+	for _, server := range clusterServers {
+		name := strings.ToLower(server.Name)
+
+		usage, ok := usages[server.HypervisorHostname]
+		if !ok {
+			usage = &hostUsage{}
+			usages[server.HypervisorHostname] = usage
+		}
+
+		switch {
+		case strings.Contains(name, "master"):
+			usage.masters = append(usage.masters, server.Name)
+		case strings.Contains(name, "worker"):
+			usage.workers = append(usage.workers, server.Name)
+		default:
+			usage.others = append(usage.others, server.Name)
+		}
+	}
+
+	sort.Slice(allHypervisors, func(i, j int) bool {
+		return allHypervisors[i].HypervisorHostname < allHypervisors[j].HypervisorHostname
+	})
+
+	for _, hypervisor := range allHypervisors {
+		var (
+			usage   = usages[hypervisor.HypervisorHostname]
+			vms     = "no VMs of the cluster"
+			fits    string
+			finding Finding
+		)
+
+		if usage == nil {
+			usage = &hostUsage{}
+		} else {
+			sort.Strings(usage.masters)
+			sort.Strings(usage.workers)
+			sort.Strings(usage.others)
+			vms = strings.Join(append(append(append([]string{}, usage.masters...), usage.workers...), usage.others...), ", ")
+		}
+
+		finding = newFinding(hypervisor.HypervisorHostname, SeverityInfo, "Host %s (%s/%s) has %s; %d of %d vCPUs, %d of %d MB memory and %d of %d GB disk free",
+			hypervisor.HypervisorHostname,
+			hypervisor.State,
+			hypervisor.Status,
+			vms,
+			hypervisor.VCPUs-hypervisor.VCPUsUsed,
+			hypervisor.VCPUs,
+			hypervisor.MemoryMB-hypervisor.MemoryMBUsed,
+			hypervisor.MemoryMB,
+			hypervisor.LocalGB-hypervisor.LocalGBUsed,
+			hypervisor.LocalGB,
+		)
+		finding.Fields = map[string]string{
+			"masters": strconv.Itoa(len(usage.masters)),
+			"workers": strconv.Itoa(len(usage.workers)),
+		}
+
+		if flavor != nil {
+			fits = strconv.FormatBool(flavorFits(hypervisor, flavor))
+			if fits == "true" {
+				anyFits = true
+				finding.Message += fmt.Sprintf("; another %s fits", flavor.Name)
+			} else {
+				finding.Message += fmt.Sprintf("; another %s does not fit", flavor.Name)
+			}
+			finding.Fields["fits"] = fits
+		}
+
+		if len(usage.masters) > 1 {
+			finding.Severity = SeverityError
+			finding.Message += fmt.Sprintf("; masters %s share this host", strings.Join(usage.masters, ", "))
+		}
+
+		findings = append(findings, finding)
+		delete(usages, hypervisor.HypervisorHostname)
+	}
+
+	// Servers whose host is not in the hypervisor list, for example while they are being scheduled.
+	for host, usage := range usages {
+		if len(usage.masters) > 1 && host != "" {
+			findings = append(findings, newFinding(host, SeverityError, "Masters %s share host %s", strings.Join(usage.masters, ", "), host))
+		}
+	}
+
+	if flavor != nil && len(allHypervisors) > 0 && !anyFits {
+		findings = append(findings, newFinding(flavor.Name, SeverityWarning, "No host has room for another %s (%d vCPUs, %d MB memory, %d GB disk)", flavor.Name, flavor.VCPUs, flavor.RAM, flavor.Disk))
+	}
+
+	return findings
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+func testHypervisor(name string, vcpus, vcpusUsed, memoryMB, memoryMBUsed, localGB, localGBUsed int) hypervisors.Hypervisor {
+	return hypervisors.Hypervisor{
+		HypervisorHostname: name,
+		State:              "up",
+		Status:             "enabled",
+		VCPUs:              vcpus,
+		VCPUsUsed:          vcpusUsed,
+		MemoryMB:           memoryMB,
+		MemoryMBUsed:       memoryMBUsed,
+		LocalGB:            localGB,
+		LocalGBUsed:        localGBUsed,
+	}
+}
+
+func TestFlavorFits(t *testing.T) {
+	flavor := &flavors.Flavor{Name: "medium", VCPUs: 4, RAM: 16384, Disk: 100}
+
+	down := testHypervisor("down", 64, 0, 262144, 0, 0, 0)
+	down.State = "down"
+	disabled := testHypervisor("disabled", 64, 0, 262144, 0, 0, 0)
+	disabled.Status = "disabled"
+
+	tests := []struct {
+		hypervisor hypervisors.Hypervisor
+		want       bool
+	}{
+		{testHypervisor("roomy", 64, 0, 262144, 0, 1000, 0), true},
+		{testHypervisor("exactly", 8, 4, 32768, 16384, 200, 100), true},
+		{testHypervisor("vcpus", 8, 5, 262144, 0, 0, 0), false},
+		{testHypervisor("memory", 64, 0, 32768, 16385, 0, 0), false},
+		{testHypervisor("disk", 64, 0, 262144, 0, 150, 51), false},
+		{testHypervisor("san", 64, 0, 262144, 0, 0, 0), true},
+		{down, false},
+		{disabled, false},
+	}
+
+	for _, test := range tests {
+		if got := flavorFits(test.hypervisor, flavor); got != test.want {
+			t.Errorf("%s: flavorFits is %v, want %v", test.hypervisor.HypervisorHostname, got, test.want)
+		}
+	}
+}
+
+func TestPlacementFindings(t *testing.T) {
+	var (
+		flavor = &flavors.Flavor{Name: "medium", VCPUs: 4, RAM: 16384, Disk: 100}
+	)
+
+	server := func(name string, host string) servers.Server {
+		return servers.Server{Name: name, HypervisorHostname: host}
+	}
+
+	tests := []struct {
+		name        string
+		servers     []servers.Server
+		hypervisors []hypervisors.Hypervisor
+		flavor      *flavors.Flavor
+		severities  map[string]Severity
+		fits        map[string]string
+		messages    map[string]string
+	}{
+		{
+			name: "masters spread out",
+			servers: []servers.Server{
+				server("test-abc12-master-0", "host-a"),
+				server("test-abc12-master-1", "host-b"),
+				server("test-abc12-worker-0-x7k2p", "host-a"),
+			},
+			hypervisors: []hypervisors.Hypervisor{
+				testHypervisor("host-b", 64, 8, 262144, 32768, 0, 0),
+				testHypervisor("host-a", 64, 8, 262144, 32768, 0, 0),
+			},
+			flavor:     flavor,
+			severities: map[string]Severity{"host-a": SeverityInfo, "host-b": SeverityInfo},
+			fits:       map[string]string{"host-a": "true", "host-b": "true"},
+			messages: map[string]string{
+				"host-a": "Host host-a (up/enabled) has test-abc12-master-0, test-abc12-worker-0-x7k2p; 56 of 64 vCPUs, 229376 of 262144 MB memory and 0 of 0 GB disk free; another medium fits",
+			},
+		},
+		{
+			name: "two masters on one host",
+			servers: []servers.Server{
+				server("test-abc12-master-1", "host-a"),
+				server("test-abc12-master-0", "host-a"),
+				server("test-abc12-master-2", "host-b"),
+			},
+			hypervisors: []hypervisors.Hypervisor{
+				testHypervisor("host-a", 64, 8, 262144, 32768, 0, 0),
+				testHypervisor("host-b", 64, 4, 262144, 16384, 0, 0),
+			},
+			flavor:     flavor,
+			severities: map[string]Severity{"host-a": SeverityError, "host-b": SeverityInfo},
+			messages: map[string]string{
+				"host-a": "masters test-abc12-master-0, test-abc12-master-1 share this host",
+			},
+		},
+		{
+			name: "two masters on a host which is not listed",
+			servers: []servers.Server{
+				server("test-abc12-master-0", "host-c"),
+				server("test-abc12-master-1", "host-c"),
+			},
+			hypervisors: []hypervisors.Hypervisor{
+				testHypervisor("host-a", 64, 0, 262144, 0, 0, 0),
+			},
+			flavor:     flavor,
+			severities: map[string]Severity{"host-a": SeverityInfo, "host-c": SeverityError},
+		},
+		{
+			name: "no host has room",
+			servers: []servers.Server{
+				server("test-abc12-master-0", "host-a"),
+			},
+			hypervisors: []hypervisors.Hypervisor{
+				testHypervisor("host-a", 16, 14, 262144, 0, 0, 0),
+				testHypervisor("host-b", 64, 0, 20000, 8192, 0, 0),
+				testHypervisor("host-c", 64, 0, 262144, 0, 500, 450),
+			},
+			flavor:     flavor,
+			severities: map[string]Severity{"host-a": SeverityInfo, "host-b": SeverityInfo, "host-c": SeverityInfo, "medium": SeverityWarning},
+			fits:       map[string]string{"host-a": "false", "host-b": "false", "host-c": "false"},
+			messages: map[string]string{
+				"host-b": "64 of 64 vCPUs, 11808 of 20000 MB memory and 0 of 0 GB disk free; another medium does not fit",
+				"medium": "No host has room for another medium (4 vCPUs, 16384 MB memory, 100 GB disk)",
+			},
+		},
+		{
+			name: "without a flavor",
+			servers: []servers.Server{
+				server("test-abc12-master-0", "host-a"),
+			},
+			hypervisors: []hypervisors.Hypervisor{
+				testHypervisor("host-a", 16, 14, 262144, 0, 0, 0),
+			},
+			severities: map[string]Severity{"host-a": SeverityInfo},
+			fits:       map[string]string{"host-a": ""},
+		},
+	}
+
+	for _, test := range tests {
+		findings := placementFindings(test.servers, test.hypervisors, test.flavor)
+
+		byName := make(map[string]Finding)
+		for _, finding := range findings {
+			byName[finding.Name] = finding
+		}
+		if len(byName) != len(test.severities) {
+			t.Errorf("%s: got findings %+v", test.name, findings)
+		}
+
+		for name, severity := range test.severities {
+			if byName[name].Severity != severity {
+				t.Errorf("%s: %s has severity %q, want %q", test.name, name, byName[name].Severity, severity)
+			}
+		}
+		for name, fits := range test.fits {
+			if byName[name].Fields["fits"] != fits {
+				t.Errorf("%s: %s fits is %q, want %q", test.name, name, byName[name].Fields["fits"], fits)
+			}
+		}
+		for name, message := range test.messages {
+			if !strings.Contains(byName[name].Message, message) {
+				t.Errorf("%s: %s says %q, want %q", test.name, name, byName[name].Message, message)
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	PortsName = "Network Ports"
+)
+
+type Ports struct {
+	services *Services
+}
+
+func NewPorts(services *Services) ([]RunnableObject, []error) {
+	var (
+		pos  []*Ports
+		errs []error
+		ros  []RunnableObject
+	)
+
+	pos, errs = innerNewPorts(services)
+
+	ros = make([]RunnableObject, len(pos))
+	// Go does not support type converting the entire array.
+	// So we do it manually.
+	for i, v := range pos {
+		ros[i] = RunnableObject(v)
+	}
+
+	return ros, errs
+}
+
+func NewPortsAlt(services *Services) ([]*Ports, []error) {
+	return innerNewPorts(services)
+}
+
+func innerNewPorts(services *Services) ([]*Ports, []error) {
+	var (
+		pos  []*Ports
+		errs []error
+	)
+
+	pos = make([]*Ports, 1)
+	errs = make([]error, 1)
+
+	pos[0] = &Ports{
+		services: services,
+	}
+
+	return pos, errs
+}
+
+func (pos *Ports) Name() (string, error) {
+	return PortsName, nil
+}
+
+func (pos *Ports) ObjectName() (string, error) {
+	return PortsName, nil
+}
+
+func (pos *Ports) Run() error {
+	// Nothing needs to be done here.
+	return nil
+}
+
+// ClusterStatus looks at every port on the networks the cluster uses.  The cluster is the servers
+// whose names start with the infraID, and the bastion which is named after the cluster.
+func (pos *Ports) ClusterStatus(ctx context.Context) CheckResult {
+	var (
+		cancel          context.CancelFunc
+		cloud           string
+		infraID         string
+		bastionName     string
+		allServers      []servers.Server
+		clusterServers  []servers.Server
+		serverIDs       = sets.New[string]()
+		allPorts        []ports.Port
+		clusterNetworks = sets.New[string]()
+		networkPorts    []ports.Port
+		findings        []Finding
+		err             error
+	)
+
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cloud = pos.services.GetCloud()
+	infraID = pos.services.GetMetadata().GetInfraID()
+	bastionName = pos.services.GetMetadata().GetClusterName()
+	log.Debugf("ClusterStatus: infraID = %s, bastionName = %s", infraID, bastionName)
+
+	allServers, err = getAllServers(ctx, cloud)
+	if err != nil {
+		return unknownCheckResult(PortsName, "getAllServers returns error %v", err)
+	}
+
+	for _, server := range allServers {
+		if !isClusterServer(server.Name, infraID, bastionName) {
+			continue
+		}
+		log.Debugf("ClusterStatus: FOUND server = %s", server.Name)
+
+		clusterServers = append(clusterServers, server)
+		serverIDs.Insert(server.ID)
+	}
+
+	// An empty name lists every port in the project.
+	allPorts, err = findPorts(ctx, cloud, "")
+	if err != nil {
+		return unknownCheckResult(PortsName, "findPorts returns error %v", err)
+	}
+
+	for _, port := range allPorts {
+		if isClusterPort(port, serverIDs, infraID, bastionName) {
+			clusterNetworks.Insert(port.NetworkID)
+		}
+	}
+	log.Debugf("ClusterStatus: clusterNetworks = %v", sets.List(clusterNetworks))
+
+	if clusterNetworks.Len() == 0 {
+		return newCheckResult([]Finding{newFinding(PortsName, SeverityError, "No ports found for %s", infraID)})
+	}
+
+	for _, port := range allPorts {
+		if clusterNetworks.Has(port.NetworkID) {
+			networkPorts = append(networkPorts, port)
+		}
+	}
+
+	findings = append(findings, unattachedPortFindings(networkPorts, serverIDs, infraID, bastionName)...)
+	findings = append(findings, duplicateIPFindings(networkPorts)...)
+	findings = append(findings, serverPortFindings(clusterServers, networkPorts)...)
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding(PortsName, SeverityInfo, "%d ports on %d networks", len(networkPorts), clusterNetworks.Len()))
+	}
+
+	return newCheckResult(findings)
+}
+
+func isClusterServer(name string, infraID string, bastionName string) bool {
+	name = strings.ToLower(name)
+
+	return strings.HasPrefix(name, infraID) || name == strings.ToLower(bastionName)
+}
+
+// isClusterPort is whether a port was created for the cluster, either by the installer or by
+// createServer for the bastion, or is attached to one of its servers.
+func isClusterPort(port ports.Port, serverIDs sets.Set[string], infraID string, bastionName string) bool {
+	name := strings.ToLower(port.Name)
+
+	switch {
+	case serverIDs.Has(port.DeviceID):
+		return true
+	case strings.HasPrefix(name, infraID):
+		return true
+	case name == strings.ToLower(fmt.Sprintf("%s-port", bastionName)):
+		return true
+	}
+	return false
+}
+
+// unattachedPortFindings warns about cluster ports which nothing uses any more.  They still hold
+// their IP addresses.  The API and ingress VIP ports never have a device, so they are skipped.
+func unattachedPortFindings(networkPorts []ports.Port, serverIDs sets.Set[string], infraID string, bastionName string) []Finding {
+	var (
+		vipAddresses = sets.New[string]()
+		findings     []Finding
+	)
+
+	for _, port := range networkPorts {
+		for _, pair := range port.AllowedAddressPairs {
+			vipAddresses.Insert(pair.IPAddress)
+		}
+	}
+
+	for _, port := range networkPorts {
+		if port.DeviceID != "" || !isClusterPort(port, serverIDs, infraID, bastionName) {
+			continue
+		}
+		if isVIPPort(port, vipAddresses) {
+			log.Debugf("unattachedPortFindings: %s is a VIP port", portName(port))
+			continue
+		}
+
+		finding := newFinding(portName(port), SeverityWarning, "Port %s (%s) with IP address (%s) has no device",
+			portName(port),
+			port.MACAddress,
+			strings.Join(portIPAddresses(port), ", "),
+		)
+		finding.Fields = map[string]string{
+			"device": "none",
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// isVIPPort is whether a port holds a VIP for the servers rather than belonging to one.  The
+// installer names them <infraID>-api-port and <infraID>-ingress-port, and the servers which may
+// answer on the VIP have it as an allowed address pair.
+func isVIPPort(port ports.Port, vipAddresses sets.Set[string]) bool {
+	name := strings.ToLower(port.Name)
+
+	if strings.HasSuffix(name, "-api-port") || strings.HasSuffix(name, "-ingress-port") {
+		return true
+	}
+	if len(port.AllowedAddressPairs) > 0 {
+		return true
+	}
+	for _, ipAddress := range portIPAddresses(port) {
+		if vipAddresses.Has(ipAddress) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// duplicateIPFindings fails an IP address which more than one port on the same networks has.
+func duplicateIPFindings(networkPorts []ports.Port) []Finding {
+	var (
+		portsByIP = make(map[string][]string)
+		ipAddrs   []string
+		findings  []Finding
+	)
+
+	for _, port := range networkPorts {
+		for _, ipAddress := range portIPAddresses(port) {
+			portsByIP[ipAddress] = append(portsByIP[ipAddress], portName(port))
+		}
+	}
+
+	for ipAddress, names := range portsByIP {
+		if len(names) > 1 {
+			ipAddrs = append(ipAddrs, ipAddress)
+		}
+	}
+	sort.Strings(ipAddrs)
+
+	for _, ipAddress := range ipAddrs {
+		finding := newFinding(ipAddress, SeverityError, "IP address %s is used by more than one port: %s", ipAddress, strings.Join(portsByIP[ipAddress], ", "))
+		finding.Fields = map[string]string{
+			"ports": strconv.Itoa(len(portsByIP[ipAddress])),
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// serverPortFindings compares the MAC and IP address which findIpAddress reports for a server with
+// the ports attached to that server.
+func serverPortFindings(clusterServers []servers.Server, networkPorts []ports.Port) []Finding {
+	var (
+		findings []Finding
+	)
+
+	for _, server := range clusterServers {
+		var (
+			macAddress string
+			ipAddress  string
+			found      *ports.Port
+			finding    Finding
+			err        error
+		)
+
+		macAddress, ipAddress, err = findIpAddress(server)
+		if err != nil || macAddress == "" {
+			log.Debugf("serverPortFindings: %s: findIpAddress returns %v", server.Name, err)
+			continue
+		}
+
+		for i := range networkPorts {
+			if networkPorts[i].DeviceID == server.ID && strings.EqualFold(networkPorts[i].MACAddress, macAddress) {
+				found = &networkPorts[i]
+				break
+			}
+		}
+
+		switch {
+		case found == nil:
+			finding = newFinding(server.Name, SeverityError, "%s has MAC address (%s) but no port with that MAC address is attached to it", server.Name, macAddress)
+			finding.Fields = map[string]string{
+				"port": "mismatch",
+			}
+		case !sets.New(portIPAddresses(*found)...).Has(ipAddress):
+			finding = newFinding(server.Name, SeverityError, "%s has IP address (%s) but its port %s has (%s)", server.Name, ipAddress, portName(*found), strings.Join(portIPAddresses(*found), ", "))
+			finding.Fields = map[string]string{
+				"port": "mismatch",
+			}
+		default:
+			finding = newFinding(server.Name, SeverityInfo, "%s has port %s with MAC address (%s) and IP address (%s)", server.Name, portName(*found), macAddress, ipAddress)
+			finding.Fields = map[string]string{
+				"port": "match",
+			}
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// portName is the name of a port, or its ID when it does not have one.
+func portName(port ports.Port) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return port.ID
+}
+
+func portIPAddresses(port ports.Port) []string {
+	var (
+		ipAddrs []string
+	)
+
+	for _, fixedIP := range port.FixedIPs {
+		ipAddrs = append(ipAddrs, fixedIP.IPAddress)
+	}
+
+	return ipAddrs
+}
+
+func (pos *Ports) Priority() (int, error) {
+	return -1, nil
+}
+
+func (pos *Ports) Dependencies() []string {
+	return nil
+}
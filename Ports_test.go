@@ -0,0 +1,63 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestUnattachedPortFindings(t *testing.T) {
+	var (
+		infraID   = "mycluster-abcde"
+		serverIDs = sets.New("server-1")
+	)
+
+	fixedIP := func(ipAddress string) []ports.IP {
+		return []ports.IP{{IPAddress: ipAddress}}
+	}
+
+	networkPorts := []ports.Port{
+		// A master and its VIPs
+		{ID: "1", Name: infraID + "-master-0", DeviceID: "server-1", FixedIPs: fixedIP("10.0.0.10"),
+			AllowedAddressPairs: []ports.AddressPair{{IPAddress: "10.0.0.5"}, {IPAddress: "10.0.0.7"}}},
+		{ID: "2", Name: infraID + "-api-port", FixedIPs: fixedIP("10.0.0.5")},
+		{ID: "3", Name: infraID + "-ingress-port", FixedIPs: fixedIP("10.0.0.7")},
+		// A VIP which is only known by the allowed address pair
+		{ID: "4", Name: infraID + "-other-vip", FixedIPs: fixedIP("10.0.0.7")},
+		// A leaked worker port and a leaked bastion port
+		{ID: "5", Name: infraID + "-worker-0", FixedIPs: fixedIP("10.0.0.20")},
+		{ID: "6", Name: "mycluster-port", FixedIPs: fixedIP("10.0.0.2")},
+		// Someone else's port
+		{ID: "7", Name: "other", FixedIPs: fixedIP("10.0.0.30")},
+	}
+
+	findings := unattachedPortFindings(networkPorts, serverIDs, infraID, "mycluster")
+
+	var names []string
+	for _, finding := range findings {
+		names = append(names, finding.Name)
+		if finding.Severity != SeverityWarning {
+			t.Errorf("%s has severity %s", finding.Name, finding.Severity)
+		}
+	}
+	want := []string{infraID + "-worker-0", "mycluster-port"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Errorf("unattachedPortFindings = %v, want %v", names, want)
+	}
+}
@@ -44,10 +44,16 @@ func printUsage(executableName string) {
 
 	fmt.Fprintf(os.Stderr, "Usage: %s [ "+
 		"check-alive "+
+		"| cleanup-containers "+
+		"| config show "+
 		"| create-bastion "+
 		"| create-rhcos "+
 		"| create-cluster "+
+		"| delete-cluster "+
+		"| generate-install-config "+
+		"| preflight "+
 		"| send-metadata "+
+		"| server-certs "+
 		"| watch-installation "+
 		"| watch-create"+
 		" ]\n", executableName)
@@ -57,10 +63,16 @@ func main() {
 	var (
 		executableName          string
 		checkAliveFlags         *flag.FlagSet
+		cleanupContainersFlags  *flag.FlagSet
+		configFlags             *flag.FlagSet
 		createBastionFlags      *flag.FlagSet
 		createClusterFlags      *flag.FlagSet
 		createRhcosFlags        *flag.FlagSet
+		deleteClusterFlags      *flag.FlagSet
+		generateInstallFlags    *flag.FlagSet
+		preflightFlags          *flag.FlagSet
 		sendMetadataFlags       *flag.FlagSet
+		serverCertsFlags        *flag.FlagSet
 		watchInstallationFlags  *flag.FlagSet
 		watchCreateClusterFlags *flag.FlagSet
 		err                     error
@@ -83,10 +95,16 @@ func main() {
 	}
 
 	checkAliveFlags = flag.NewFlagSet("check-alive", flag.ExitOnError)
+	cleanupContainersFlags = flag.NewFlagSet("cleanup-containers", flag.ExitOnError)
+	configFlags = flag.NewFlagSet("config", flag.ExitOnError)
 	createBastionFlags = flag.NewFlagSet("create-bastion", flag.ExitOnError)
 	createClusterFlags = flag.NewFlagSet("create-cluster", flag.ExitOnError)
 	createRhcosFlags = flag.NewFlagSet("create-rhcos", flag.ExitOnError)
+	deleteClusterFlags = flag.NewFlagSet("delete-cluster", flag.ExitOnError)
+	generateInstallFlags = flag.NewFlagSet("generate-install-config", flag.ExitOnError)
+	preflightFlags = flag.NewFlagSet("preflight", flag.ExitOnError)
 	sendMetadataFlags = flag.NewFlagSet("send-metadata", flag.ExitOnError)
+	serverCertsFlags = flag.NewFlagSet("server-certs", flag.ExitOnError)
 	watchInstallationFlags = flag.NewFlagSet("watch-cluster", flag.ExitOnError)
 	watchCreateClusterFlags = flag.NewFlagSet("watch-create", flag.ExitOnError)
 
@@ -94,6 +112,12 @@ func main() {
 	case "check-alive":
 		err = checkAliveCommand(checkAliveFlags, os.Args[2:])
 
+	case "cleanup-containers":
+		err = cleanupContainersCommand(cleanupContainersFlags, os.Args[2:])
+
+	case "config":
+		err = configCommand(configFlags, os.Args[2:])
+
 	case "create-bastion":
 		err = createBastionCommand(createBastionFlags, os.Args[2:])
 
@@ -103,9 +127,21 @@ func main() {
 	case "create-rhcos":
 		err = createRhcosCommand(createRhcosFlags, os.Args[2:])
 
+	case "delete-cluster":
+		err = deleteClusterCommand(deleteClusterFlags, os.Args[2:])
+
+	case "generate-install-config":
+		err = generateInstallConfigCommand(generateInstallFlags, os.Args[2:])
+
+	case "preflight":
+		err = preflightCommand(preflightFlags, os.Args[2:])
+
 	case "send-metadata":
 		err = sendMetadataCommand(sendMetadataFlags, os.Args[2:])
 
+	case "server-certs":
+		err = serverCertsCommand(serverCertsFlags, os.Args[2:])
+
 	case "watch-installation":
 		err = watchInstallationCommand(watchInstallationFlags, os.Args[2:])
 
@@ -118,8 +154,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Keep stdout for the output of the command, such as watch-create --output json
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
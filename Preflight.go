@@ -0,0 +1,375 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/limits"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/quotas"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// preflightRequest is a number of VMs which are about to be created with one flavor.
+type preflightRequest struct {
+	FlavorName string
+	Count      int
+}
+
+// quotaUsage is one quota of the project.  A negative Limit is unlimited.
+type quotaUsage struct {
+	Resource  string
+	Unit      string
+	Limit     int
+	Used      int
+	Requested int
+}
+
+func (q quotaUsage) exceeded() bool {
+	return q.Limit >= 0 && q.Used+q.Requested > q.Limit
+}
+
+func (q quotaUsage) String() string {
+	var (
+		limit = "unlimited"
+	)
+
+	if q.Limit >= 0 {
+		limit = fmt.Sprintf("%d%s", q.Limit, q.Unit)
+	}
+
+	return fmt.Sprintf("%s: %d%s used + %d%s requested of %s", q.Resource, q.Used, q.Unit, q.Requested, q.Unit, limit)
+}
+
+// addPlannedFlags adds the flags for the rest of the cluster, which the preflight counts along with
+// the VMs the command creates.
+func addPlannedFlags(flags *flag.FlagSet) (ptrPlannedVMs *string, ptrPlannedFlavorName *string) {
+	ptrPlannedVMs = flags.String("plannedVMs", "0", "How many more VMs the cluster will need, for the preflight")
+	ptrPlannedFlavorName = flags.String("plannedFlavorName", "", "The flavor of the planned VMs (default --flavorName)")
+	return
+}
+
+// preflightRequests turns the flags into what runPreflight checks.
+func preflightRequests(flavorName string, count int, plannedVMs string, plannedFlavorName string) ([]preflightRequest, error) {
+	var (
+		planned int
+		err     error
+	)
+
+	planned, err = strconv.Atoi(plannedVMs)
+	if err != nil || planned < 0 {
+		return nil, fmt.Errorf("Error: plannedVMs is not a number (%s)\n", plannedVMs)
+	}
+
+	if plannedFlavorName == "" {
+		plannedFlavorName = flavorName
+	}
+
+	if plannedFlavorName == flavorName {
+		return []preflightRequest{{FlavorName: flavorName, Count: count + planned}}, nil
+	}
+
+	return []preflightRequest{
+		{FlavorName: flavorName, Count: count},
+		{FlavorName: plannedFlavorName, Count: planned},
+	}, nil
+}
+
+// runPreflight checks that the project has the quota for every VM in requests, and tells whether
+// the hypervisors have room for them.  It returns an error listing every quota which would be
+// exceeded.  Running out of room on the hypervisors is only a warning, since other projects may
+// free some by the time the VMs are scheduled, and the hypervisors may not be visible at all.
+func runPreflight(ctx context.Context, cloud string, requests []preflightRequest, out io.Writer) error {
+	var (
+		connCompute    *gophercloud.ServiceClient
+		connNetwork    *gophercloud.ServiceClient
+		requested      []flavors.Flavor
+		counts         []int
+		usages         []quotaUsage
+		allHypervisors []hypervisors.Hypervisor
+		exceeded       []string
+		err            error
+	)
+
+	for _, request := range requests {
+		var (
+			flavor flavors.Flavor
+		)
+
+		if request.Count <= 0 {
+			continue
+		}
+
+		flavor, err = findFlavor(ctx, cloud, request.FlavorName)
+		if err != nil {
+			return err
+		}
+		log.Debugf("runPreflight: %d x %s (%d vCPUs, %d MB)", request.Count, flavor.Name, flavor.VCPUs, flavor.RAM)
+
+		requested = append(requested, flavor)
+		counts = append(counts, request.Count)
+	}
+
+	if len(requested) == 0 {
+		return nil
+	}
+
+	connCompute, err = getServiceClient(ctx, "compute", cloud)
+	if err != nil {
+		return fmt.Errorf("runPreflight: getServiceClient returns %v", err)
+	}
+
+	connNetwork, err = getServiceClient(ctx, "network", cloud)
+	if err != nil {
+		return fmt.Errorf("runPreflight: getServiceClient returns %v", err)
+	}
+
+	usages, err = getQuotaUsages(ctx, connCompute, connNetwork)
+	if err != nil {
+		return err
+	}
+
+	usages = addFootprint(usages, requested, counts)
+
+	fmt.Fprintf(out, "Quota of cloud %s:\n", cloud)
+	for _, usage := range usages {
+		fmt.Fprintf(out, "    %v\n", usage)
+		if usage.exceeded() {
+			exceeded = append(exceeded, usage.String())
+		}
+	}
+
+	allHypervisors, err = getAllHypervisors(ctx, connCompute)
+	if err != nil {
+		// Listing the hypervisors usually needs the admin role.
+		fmt.Fprintf(out, "Warning: Could not list the hypervisors (%v), skipping the capacity check\n", err)
+	} else {
+		for i, flavor := range requested {
+			fit := flavorCapacity(allHypervisors, &flavor)
+			if fit < counts[i] {
+				fmt.Fprintf(out, "Warning: The hypervisors only have room for %d of the %d %s VMs\n", fit, counts[i], flavor.Name)
+			} else {
+				fmt.Fprintf(out, "The hypervisors have room for %d %s VMs\n", fit, flavor.Name)
+			}
+		}
+	}
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("Error: Creating %s would exceed the quota of cloud %s:\n    %s", describeRequests(requested, counts), cloud, strings.Join(exceeded, "\n    "))
+	}
+
+	return nil
+}
+
+// getQuotaUsages returns the compute limits and the port quota of the project, with nothing
+// requested yet.  Reserved ports count as used, since they are on their way.
+func getQuotaUsages(ctx context.Context, connCompute *gophercloud.ServiceClient, connNetwork *gophercloud.ServiceClient) ([]quotaUsage, error) {
+	var (
+		computeLimits *limits.Limits
+		projectID     string
+		quotaDetail   *quotas.QuotaDetailSet
+		err           error
+	)
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Minute,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getQuotaUsages", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("getQuotaUsages: duration = %v, calling limits.Get", leftInContext(ctx))
+		computeLimits, err2 = limits.Get(ctx, connCompute, nil).Extract()
+		if gophercloud.ResponseCodeIs(err2, http.StatusForbidden) || gophercloud.ResponseCodeIs(err2, http.StatusNotFound) {
+			return true, err2
+		}
+		if err2 != nil {
+			log.Debugf("getQuotaUsages: limits.Get returned error %v", err2)
+			return false, nil
+		}
+
+		return true, nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not get the compute limits: %v", err)
+	}
+
+	projectID, err = authProjectID(connNetwork)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("getQuotaUsages: projectID = %s", projectID)
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("getQuotaUsages", func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		log.Debugf("getQuotaUsages: duration = %v, calling quotas.GetDetail", leftInContext(ctx))
+		quotaDetail, err2 = quotas.GetDetail(ctx, connNetwork, projectID).Extract()
+		if gophercloud.ResponseCodeIs(err2, http.StatusForbidden) || gophercloud.ResponseCodeIs(err2, http.StatusNotFound) {
+			return true, err2
+		}
+		if err2 != nil {
+			log.Debugf("getQuotaUsages: quotas.GetDetail returned error %v", err2)
+			return false, nil
+		}
+
+		return true, nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not get the network quota: %v", err)
+	}
+
+	return []quotaUsage{
+		{
+			Resource: "instances",
+			Limit:    computeLimits.Absolute.MaxTotalInstances,
+			Used:     computeLimits.Absolute.TotalInstancesUsed,
+		},
+		{
+			Resource: "vCPUs",
+			Limit:    computeLimits.Absolute.MaxTotalCores,
+			Used:     computeLimits.Absolute.TotalCoresUsed,
+		},
+		{
+			Resource: "memory",
+			Unit:     " MB",
+			Limit:    computeLimits.Absolute.MaxTotalRAMSize,
+			Used:     computeLimits.Absolute.TotalRAMUsed,
+		},
+		{
+			Resource: "ports",
+			Limit:    quotaDetail.Port.Limit,
+			Used:     quotaDetail.Port.Used + quotaDetail.Port.Reserved,
+		},
+	}, nil
+}
+
+// addFootprint adds what the VMs need to the usages from getQuotaUsages.  createServer gives
+// every VM one port.
+func addFootprint(usages []quotaUsage, requested []flavors.Flavor, counts []int) []quotaUsage {
+	for i, flavor := range requested {
+		for j := range usages {
+			switch usages[j].Resource {
+			case "instances":
+				usages[j].Requested += counts[i]
+			case "vCPUs":
+				usages[j].Requested += counts[i] * flavor.VCPUs
+			case "memory":
+				usages[j].Requested += counts[i] * flavor.RAM
+			case "ports":
+				usages[j].Requested += counts[i]
+			}
+		}
+	}
+
+	return usages
+}
+
+// flavorCapacity is how many VMs of flavor the hypervisors have room for in total.
+func flavorCapacity(allHypervisors []hypervisors.Hypervisor, flavor *flavors.Flavor) int {
+	var (
+		total = 0
+	)
+
+	if flavor.VCPUs <= 0 && flavor.RAM <= 0 {
+		// A flavor of nothing fits without limit.  Only its disk is left, which a host with
+		// its volumes on a SAN does not count.
+		return math.MaxInt
+	}
+
+	for _, hypervisor := range allHypervisors {
+		if !flavorFits(hypervisor, flavor) {
+			continue
+		}
+
+		fit := math.MaxInt
+		if flavor.VCPUs > 0 {
+			fit = min(fit, (hypervisor.VCPUs-hypervisor.VCPUsUsed)/flavor.VCPUs)
+		}
+		if flavor.RAM > 0 {
+			fit = min(fit, (hypervisor.MemoryMB-hypervisor.MemoryMBUsed)/flavor.RAM)
+		}
+		if hypervisor.LocalGB > 0 && flavor.Disk > 0 {
+			fit = min(fit, (hypervisor.LocalGB-hypervisor.LocalGBUsed)/flavor.Disk)
+		}
+
+		total += fit
+	}
+
+	return total
+}
+
+// projectExtractor is a keystone v3 authentication result, which knows its project.
+type projectExtractor interface {
+	ExtractProject() (*tokens.Project, error)
+}
+
+// authProjectID is the project the client's token is scoped to, which is the project the VMs are
+// created in.
+func authProjectID(client *gophercloud.ServiceClient) (string, error) {
+	var (
+		result  projectExtractor
+		project *tokens.Project
+		ok      bool
+		err     error
+	)
+
+	result, ok = client.ProviderClient.GetAuthResult().(projectExtractor)
+	if !ok {
+		return "", fmt.Errorf("Error: The token does not say which project it is for, is the cloud using keystone v3?")
+	}
+
+	project, err = result.ExtractProject()
+	if err != nil {
+		return "", err
+	}
+	if project == nil || project.ID == "" {
+		return "", fmt.Errorf("Error: The token is not scoped to a project")
+	}
+
+	return project.ID, nil
+}
+
+func describeRequests(requested []flavors.Flavor, counts []int) string {
+	var (
+		parts []string
+	)
+
+	for i, flavor := range requested {
+		parts = append(parts, fmt.Sprintf("%d %s VM(s)", counts[i], flavor.Name))
+	}
+
+	return strings.Join(parts, " and ")
+}
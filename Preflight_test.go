@@ -0,0 +1,223 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+)
+
+func TestPreflightRequests(t *testing.T) {
+	tests := []struct {
+		name              string
+		count             int
+		plannedVMs        string
+		plannedFlavorName string
+		want              []preflightRequest
+		fails             bool
+	}{
+		{
+			name:       "nothing planned",
+			count:      1,
+			plannedVMs: "0",
+			want:       []preflightRequest{{FlavorName: "small", Count: 1}},
+		},
+		{
+			name:       "planned defaults to the same flavor",
+			count:      1,
+			plannedVMs: "6",
+			want:       []preflightRequest{{FlavorName: "small", Count: 7}},
+		},
+		{
+			name:              "planned with the same flavor",
+			count:             2,
+			plannedVMs:        "3",
+			plannedFlavorName: "small",
+			want:              []preflightRequest{{FlavorName: "small", Count: 5}},
+		},
+		{
+			name:              "planned with a different flavor",
+			count:             1,
+			plannedVMs:        "6",
+			plannedFlavorName: "large",
+			want:              []preflightRequest{{FlavorName: "small", Count: 1}, {FlavorName: "large", Count: 6}},
+		},
+		{
+			name:       "not a number",
+			count:      1,
+			plannedVMs: "six",
+			fails:      true,
+		},
+		{
+			name:       "negative",
+			count:      1,
+			plannedVMs: "-1",
+			fails:      true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := preflightRequests("small", test.count, test.plannedVMs, test.plannedFlavorName)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: preflightRequests returns %v", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestQuotaUsageExceeded(t *testing.T) {
+	tests := []struct {
+		usage quotaUsage
+		want  bool
+	}{
+		{quotaUsage{Limit: -1, Used: 1000, Requested: 1000}, false},
+		{quotaUsage{Limit: 10, Used: 4, Requested: 6}, false},
+		{quotaUsage{Limit: 10, Used: 4, Requested: 7}, true},
+		{quotaUsage{Limit: 10, Used: 11, Requested: 0}, true},
+		{quotaUsage{Limit: 0, Used: 0, Requested: 0}, false},
+		{quotaUsage{Limit: 0, Used: 0, Requested: 1}, true},
+	}
+
+	for _, test := range tests {
+		if got := test.usage.exceeded(); got != test.want {
+			t.Errorf("%+v: exceeded is %v, want %v", test.usage, got, test.want)
+		}
+	}
+}
+
+func TestAddFootprint(t *testing.T) {
+	usages := []quotaUsage{
+		{Resource: "instances", Limit: -1, Used: 2},
+		{Resource: "vCPUs", Limit: 100, Used: 8},
+		{Resource: "memory", Unit: "MB", Limit: -1, Used: 32768},
+		{Resource: "ports", Limit: 50, Used: 10},
+		{Resource: "volumes", Limit: 10, Used: 1},
+	}
+	requested := []flavors.Flavor{
+		{Name: "small", VCPUs: 2, RAM: 8192},
+		{Name: "large", VCPUs: 8, RAM: 32768},
+	}
+
+	got := addFootprint(usages, requested, []int{1, 3})
+
+	want := map[string]int{
+		"instances": 4,
+		"vCPUs":     2 + 3*8,
+		"memory":    8192 + 3*32768,
+		"ports":     4,
+		"volumes":   0,
+	}
+	for _, usage := range got {
+		if usage.Requested != want[usage.Resource] {
+			t.Errorf("%s: requested %d, want %d", usage.Resource, usage.Requested, want[usage.Resource])
+		}
+	}
+}
+
+func TestFlavorCapacity(t *testing.T) {
+	host := func(vcpus, vcpusUsed, memoryMB, memoryMBUsed, localGB, localGBUsed int) hypervisors.Hypervisor {
+		return hypervisors.Hypervisor{
+			State:        "up",
+			Status:       "enabled",
+			VCPUs:        vcpus,
+			VCPUsUsed:    vcpusUsed,
+			MemoryMB:     memoryMB,
+			MemoryMBUsed: memoryMBUsed,
+			LocalGB:      localGB,
+			LocalGBUsed:  localGBUsed,
+		}
+	}
+	down := host(64, 0, 262144, 0, 0, 0)
+	down.State = "down"
+	disabled := host(64, 0, 262144, 0, 0, 0)
+	disabled.Status = "disabled"
+
+	flavor := &flavors.Flavor{Name: "medium", VCPUs: 4, RAM: 16384, Disk: 100}
+
+	tests := []struct {
+		name       string
+		hypervisor []hypervisors.Hypervisor
+		flavor     *flavors.Flavor
+		want       int
+	}{
+		{
+			name:       "no hypervisors",
+			hypervisor: nil,
+			flavor:     flavor,
+			want:       0,
+		},
+		{
+			name:       "limited by vCPUs",
+			hypervisor: []hypervisors.Hypervisor{host(16, 4, 262144, 0, 0, 0)},
+			flavor:     flavor,
+			want:       3,
+		},
+		{
+			name:       "limited by memory",
+			hypervisor: []hypervisors.Hypervisor{host(64, 0, 65536, 16384, 0, 0)},
+			flavor:     flavor,
+			want:       3,
+		},
+		{
+			name:       "limited by local disk",
+			hypervisor: []hypervisors.Hypervisor{host(64, 0, 262144, 0, 500, 250)},
+			flavor:     flavor,
+			want:       2,
+		},
+		{
+			name:       "no local disk is a SAN",
+			hypervisor: []hypervisors.Hypervisor{host(64, 0, 262144, 0, 0, 0)},
+			flavor:     flavor,
+			want:       16,
+		},
+		{
+			name:       "too small, down and disabled hosts do not count",
+			hypervisor: []hypervisors.Hypervisor{host(8, 6, 262144, 0, 0, 0), down, disabled, host(8, 0, 32768, 0, 0, 0)},
+			flavor:     flavor,
+			want:       2,
+		},
+		{
+			name:       "summed over the hosts",
+			hypervisor: []hypervisors.Hypervisor{host(16, 4, 262144, 0, 0, 0), host(64, 0, 65536, 16384, 0, 0)},
+			flavor:     flavor,
+			want:       6,
+		},
+		{
+			name:       "a flavor of nothing",
+			hypervisor: []hypervisors.Hypervisor{host(16, 4, 262144, 0, 0, 0), host(64, 0, 65536, 16384, 0, 0)},
+			flavor:     &flavors.Flavor{Name: "empty"},
+			want:       math.MaxInt,
+		},
+	}
+
+	for _, test := range tests {
+		if got := flavorCapacity(test.hypervisor, test.flavor); got != test.want {
+			t.Errorf("%s: flavorCapacity is %d, want %d", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/imagedata"
+	"github.com/gophercloud/gophercloud/v2/openstack/image/v2/images"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	rhcosArchitecture = "ppc64le"
+	rhcosPlatform     = "openstack"
+	rhcosFormat       = "qcow2.gz"
+
+	// How long deleting an image which failed to upload may take
+	deleteImageTimeout = 2 * time.Minute
+)
+
+// coreosStream is the part of the CoreOS stream metadata, as printed by
+// openshift-install coreos print-stream-json, which is needed to find the OpenStack image.
+type coreosStream struct {
+	Stream        string                      `json:"stream"`
+	Architectures map[string]coreosStreamArch `json:"architectures"`
+}
+
+type coreosStreamArch struct {
+	Artifacts map[string]coreosStreamPlatform `json:"artifacts"`
+}
+
+type coreosStreamPlatform struct {
+	Release string                        `json:"release"`
+	Formats map[string]coreosStreamFormat `json:"formats"`
+}
+
+type coreosStreamFormat struct {
+	Disk *coreosStreamArtifact `json:"disk"`
+}
+
+type coreosStreamArtifact struct {
+	Location           string `json:"location"`
+	Sha256             string `json:"sha256"`
+	UncompressedSha256 string `json:"uncompressed-sha256"`
+}
+
+// rhcosArtifact is the OpenStack image of the stream for ppc64le.
+type rhcosArtifact struct {
+	Name               string
+	Release            string
+	Location           string
+	UncompressedSha256 string
+}
+
+// readCoreOSStream reads the stream metadata from streamFilename, or from the installer when it
+// is empty.
+func readCoreOSStream(streamFilename string) (*coreosStream, error) {
+	var (
+		content []byte
+		stream  coreosStream
+		err     error
+	)
+
+	if streamFilename != "" {
+		content, err = os.ReadFile(streamFilename)
+		if err != nil {
+			return nil, fmt.Errorf("Error: Could not read %s: %v", streamFilename, err)
+		}
+	} else {
+		content, err = runSplitCommandNoErr([]string{
+			"openshift-install",
+			"coreos",
+			"print-stream-json",
+		}, true)
+		if err != nil {
+			return nil, fmt.Errorf("Error: openshift-install coreos print-stream-json returns %v", err)
+		}
+	}
+
+	err = json.Unmarshal(content, &stream)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse the CoreOS stream metadata: %v", err)
+	}
+	log.Debugf("readCoreOSStream: stream = %s", stream.Stream)
+
+	return &stream, nil
+}
+
+// findRHCOSArtifact picks the ppc64le OpenStack qcow2 out of the stream.  The image is named
+// after the file, without .qcow2.gz, the same as scripts/create-cluster.sh does.
+func findRHCOSArtifact(stream *coreosStream) (rhcosArtifact, error) {
+	var (
+		arch     coreosStreamArch
+		platform coreosStreamPlatform
+		format   coreosStreamFormat
+		location *url.URL
+		ok       bool
+		err      error
+	)
+
+	arch, ok = stream.Architectures[rhcosArchitecture]
+	if !ok {
+		return rhcosArtifact{}, fmt.Errorf("Error: The CoreOS stream has no %s architecture", rhcosArchitecture)
+	}
+
+	platform, ok = arch.Artifacts[rhcosPlatform]
+	if !ok {
+		return rhcosArtifact{}, fmt.Errorf("Error: The CoreOS stream has no %s artifacts for %s", rhcosPlatform, rhcosArchitecture)
+	}
+
+	format, ok = platform.Formats[rhcosFormat]
+	if !ok || format.Disk == nil || format.Disk.Location == "" {
+		return rhcosArtifact{}, fmt.Errorf("Error: The CoreOS stream has no %s disk for %s %s", rhcosFormat, rhcosArchitecture, rhcosPlatform)
+	}
+
+	location, err = url.Parse(format.Disk.Location)
+	if err != nil {
+		return rhcosArtifact{}, fmt.Errorf("Error: Could not parse %s: %v", format.Disk.Location, err)
+	}
+
+	return rhcosArtifact{
+		Name:               strings.TrimSuffix(path.Base(location.Path), "."+rhcosFormat),
+		Release:            platform.Release,
+		Location:           format.Disk.Location,
+		UncompressedSha256: format.Disk.UncompressedSha256,
+	}, nil
+}
+
+// ensureRHCOSImage returns the name of the RHCOS image in Glance.  When the image is missing, it
+// is uploaded from imageFilename, which is the uncompressed qcow2 of the artifact.
+func ensureRHCOSImage(ctx context.Context, cloud string, artifact rhcosArtifact, imageFilename string) (string, error) {
+	var (
+		connImage *gophercloud.ServiceClient
+		image     images.Image
+		checksum  string
+		err       error
+	)
+
+	image, err = findImage(ctx, cloud, artifact.Name)
+	if err == nil {
+		log.Debugf("ensureRHCOSImage: found %s (%s) with status %s", image.Name, image.ID, image.Status)
+		if image.Status != images.ImageStatusActive {
+			return "", fmt.Errorf("Error: Image %s is %s, not active", image.Name, image.Status)
+		}
+		return image.Name, nil
+	}
+	if !strings.HasPrefix(err.Error(), "Could not find image named") {
+		return "", err
+	}
+
+	if imageFilename == "" {
+		return "", fmt.Errorf("Error: Image %s is not in Glance, download %s and use --rhcosImageFile to upload it", artifact.Name, artifact.Location)
+	}
+
+	if artifact.UncompressedSha256 != "" {
+		checksum, err = checksumFile(imageFilename)
+		if err != nil {
+			return "", err
+		}
+		if checksum != artifact.UncompressedSha256 {
+			return "", fmt.Errorf("Error: %s has SHA-256 %s, but %s should have %s.  Is it the uncompressed qcow2?", imageFilename, checksum, artifact.Name, artifact.UncompressedSha256)
+		}
+	}
+
+	connImage, err = getServiceClient(ctx, "image", cloud)
+	if err != nil {
+		return "", fmt.Errorf("ensureRHCOSImage: getServiceClient returns %v", err)
+	}
+
+	fmt.Printf("Uploading %s as image %s...\n", imageFilename, artifact.Name)
+
+	err = uploadImage(ctx, connImage, artifact, imageFilename)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Println("Done!")
+
+	return artifact.Name, nil
+}
+
+// uploadImage creates the image, uploads the qcow2 into it, and waits for Glance to make it active.
+// An image which failed to upload is deleted again, so that the next run does not find it.
+func uploadImage(ctx context.Context, connImage *gophercloud.ServiceClient, artifact rhcosArtifact, imageFilename string) error {
+	var (
+		image *images.Image
+		file  *os.File
+		err   error
+	)
+
+	image, err = images.Create(ctx, connImage, images.CreateOpts{
+		Name:            artifact.Name,
+		ContainerFormat: "bare",
+		DiskFormat:      "qcow2",
+		Tags:            []string{"rhcos"},
+		Properties: map[string]string{
+			"architecture": rhcosArchitecture,
+			"os_distro":    "rhcos",
+			"os_version":   artifact.Release,
+		},
+	}).Extract()
+	if err != nil {
+		return fmt.Errorf("Error: Could not create image %s: %v", artifact.Name, err)
+	}
+	log.Debugf("uploadImage: created %s (%s)", image.Name, image.ID)
+
+	file, err = os.Open(imageFilename)
+	if err != nil {
+		return deleteFailedImage(ctx, connImage, image, err)
+	}
+	defer file.Close()
+
+	err = imagedata.Upload(ctx, connImage, image.ID, file).ExtractErr()
+	if err != nil {
+		return deleteFailedImage(ctx, connImage, image, fmt.Errorf("Error: Could not upload %s: %v", imageFilename, err))
+	}
+
+	err = waitForImageActive(ctx, connImage, image.ID)
+	if err != nil {
+		return deleteFailedImage(ctx, connImage, image, err)
+	}
+
+	return nil
+}
+
+// deleteFailedImage deletes an image which failed to upload and returns the upload error.  The
+// upload may have failed because ctx expired, so the delete gets its own timeout.
+func deleteFailedImage(ctx context.Context, connImage *gophercloud.ServiceClient, image *images.Image, err error) error {
+	var (
+		cancel    context.CancelFunc
+		deleteErr error
+	)
+
+	ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), deleteImageTimeout)
+	defer cancel()
+
+	deleteErr = images.Delete(ctx, connImage, image.ID).ExtractErr()
+	if deleteErr != nil {
+		log.Debugf("deleteFailedImage: images.Delete returned error %v", deleteErr)
+		return fmt.Errorf("%v, and could not delete the image %s (%s): %v", err, image.Name, image.ID, deleteErr)
+	}
+	log.Debugf("deleteFailedImage: deleted %s (%s)", image.Name, image.ID)
+
+	return err
+}
+
+func waitForImageActive(ctx context.Context, connImage *gophercloud.ServiceClient, id string) error {
+	backoff := wait.Backoff{
+		Duration: 15 * time.Second,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	return wait.ExponentialBackoffWithContext(ctx, backoff, observeBackoff("waitForImageActive", func(context.Context) (bool, error) {
+		var (
+			image *images.Image
+			err2  error
+		)
+
+		image, err2 = images.Get(ctx, connImage, id).Extract()
+		if err2 != nil {
+			log.Debugf("waitForImageActive: images.Get returned error %v", err2)
+			return false, nil
+		}
+		log.Debugf("waitForImageActive: %s is %s", image.Name, image.Status)
+
+		switch image.Status {
+		case images.ImageStatusActive:
+			return true, nil
+		case images.ImageStatusKilled, images.ImageStatusDeleted:
+			return true, fmt.Errorf("Error: Image %s is %s", image.Name, image.Status)
+		}
+
+		return false, nil
+	}))
+}
@@ -0,0 +1,302 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputJUnit = "junit"
+)
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is one thing a RunnableObject found out about the cluster, for example the state of
+// one VM.  Fields holds the values a program would want to look at, Output holds free text such
+// as the output of a command.
+type Finding struct {
+	Name     string            `json:"name"`
+	Severity Severity          `json:"severity"`
+	Message  string            `json:"message"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Output   string            `json:"output,omitempty"`
+}
+
+func newFinding(name string, severity Severity, format string, a ...interface{}) Finding {
+	return Finding{
+		Name:     name,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+// ObjectReport holds the result of one RunnableObject.
+type ObjectReport struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	CheckResult
+}
+
+func newObjectReport(name string, result CheckResult, seconds float64) ObjectReport {
+	return ObjectReport{
+		Name:        name,
+		Seconds:     seconds,
+		CheckResult: result,
+	}
+}
+
+func (or ObjectReport) failures() int {
+	var (
+		failures = 0
+	)
+
+	for _, finding := range or.Findings {
+		if finding.Severity == SeverityError {
+			failures++
+		}
+	}
+
+	return failures
+}
+
+// Report is what watch-create prints at the end.
+type Report struct {
+	Version  string         `json:"version"`
+	Release  string         `json:"release"`
+	InfraID  string         `json:"infraID"`
+	Started  time.Time      `json:"started"`
+	Verdict  CheckStatus    `json:"verdict"`
+	Failures int            `json:"failures"`
+	Objects  []ObjectReport `json:"objects"`
+}
+
+func newReport(infraID string) *Report {
+	return &Report{
+		Version: version,
+		Release: release,
+		InfraID: infraID,
+		Started: time.Now(),
+		Verdict: CheckUnknown,
+		Objects: []ObjectReport{},
+	}
+}
+
+// addObjectReport adds a result and updates the verdict for the whole cluster.
+func (r *Report) addObjectReport(objectReport ObjectReport) {
+	var (
+		statuses []CheckStatus
+	)
+
+	r.Failures += objectReport.failures()
+	r.Objects = append(r.Objects, objectReport)
+
+	for _, existing := range r.Objects {
+		statuses = append(statuses, existing.Status)
+	}
+	r.Verdict = aggregateStatus(statuses...)
+}
+
+// Healthy is whether the verdict for the whole cluster is OK, or only has warnings.
+func (r *Report) Healthy() bool {
+	return r.Verdict == CheckOK || r.Verdict == CheckWarning
+}
+
+func validOutputFormat(format string) bool {
+	switch format {
+	case OutputText, OutputJSON, OutputJUnit:
+		return true
+	}
+	return false
+}
+
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case OutputText:
+		return r.writeText(w)
+	case OutputJSON:
+		return r.writeJSON(w)
+	case OutputJUnit:
+		return r.writeJUnit(w)
+	}
+	return fmt.Errorf("Error: unknown output format %s", format)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	for _, objectReport := range r.Objects {
+		fmt.Fprintln(w, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+
+		for _, finding := range objectReport.Findings {
+			switch finding.Severity {
+			case SeverityWarning:
+				fmt.Fprintf(w, "%s: Warning: %s\n", objectReport.Name, finding.Message)
+			case SeverityError:
+				fmt.Fprintf(w, "%s: Error: %s\n", objectReport.Name, finding.Message)
+			default:
+				fmt.Fprintf(w, "%s: %s\n", objectReport.Name, finding.Message)
+			}
+			if finding.Output != "" {
+				fmt.Fprintln(w, finding.Output)
+			}
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "%s is %s\n", objectReport.Name, objectReport.Status)
+	}
+
+	fmt.Fprintln(w, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	fmt.Fprintf(w, "The cluster is %s (%d findings failed)\n", r.Verdict, r.Failures)
+
+	return nil
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	var (
+		encoder = json.NewEncoder(w)
+	)
+
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(r)
+}
+
+// The subset of the JUnit XML format which CI systems understand.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// findingText is the message, the fields and the output of a finding as lines of text.
+func findingText(finding Finding) string {
+	var (
+		builder strings.Builder
+		keys    = make([]string, 0, len(finding.Fields))
+	)
+
+	builder.WriteString(finding.Message)
+	builder.WriteString("\n")
+
+	for key := range finding.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&builder, "%s: %s\n", key, finding.Fields[key])
+	}
+
+	if finding.Output != "" {
+		builder.WriteString(finding.Output)
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func (r *Report) writeJUnit(w io.Writer) error {
+	var (
+		suites = junitTestSuites{
+			Name:     "watch-create",
+			Failures: r.Failures,
+			Time:     fmt.Sprintf("%.3f", time.Since(r.Started).Seconds()),
+		}
+		encoder = xml.NewEncoder(w)
+		err     error
+	)
+
+	for _, objectReport := range r.Objects {
+		suite := junitTestSuite{
+			Name:      objectReport.Name,
+			Tests:     len(objectReport.Findings),
+			Failures:  objectReport.failures(),
+			Time:      fmt.Sprintf("%.3f", objectReport.Seconds),
+			Timestamp: r.Started.Format("2006-01-02T15:04:05"),
+		}
+
+		for _, finding := range objectReport.Findings {
+			testCase := junitTestCase{
+				Name:      finding.Name,
+				Classname: objectReport.Name,
+			}
+			if finding.Severity == SeverityError {
+				testCase.Failure = &junitFailure{
+					Message: finding.Message,
+					Type:    string(finding.Severity),
+					Text:    findingText(finding),
+				}
+			} else {
+				testCase.SystemOut = findingText(finding)
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		suites.Tests += suite.Tests
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder.Indent("", "  ")
+	err = encoder.Encode(suites)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 )
@@ -23,8 +24,116 @@ type RunnableObject interface {
 	Name() (string, error)
 	ObjectName() (string, error)
 	Run() error
-	ClusterStatus()
+	// ctx is cancelled when the check runs out of time.
+	ClusterStatus(ctx context.Context) CheckResult
 	Priority() (int, error)
+	// The ObjectNames of the objects which have to be checked before this one.
+	Dependencies() []string
+}
+
+// CheckStatus is the outcome of a ClusterStatus call.  The values are ordered from best to worst.
+type CheckStatus int
+
+const (
+	CheckOK CheckStatus = iota
+	CheckWarning
+	CheckUnknown
+	CheckFailed
+)
+
+var checkStatusNames = map[CheckStatus]string{
+	CheckOK:      "OK",
+	CheckWarning: "Warning",
+	CheckUnknown: "Unknown",
+	CheckFailed:  "Failed",
+}
+
+func (cs CheckStatus) String() string {
+	name, ok := checkStatusNames[cs]
+	if !ok {
+		return fmt.Sprintf("CheckStatus(%d)", int(cs))
+	}
+	return name
+}
+
+func (cs CheckStatus) MarshalText() ([]byte, error) {
+	return []byte(cs.String()), nil
+}
+
+func (cs *CheckStatus) UnmarshalText(text []byte) error {
+	for status, name := range checkStatusNames {
+		if name == string(text) {
+			*cs = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown check status %s", text)
+}
+
+// CheckResult is what ClusterStatus returns.  Findings are the details behind the Status.
+type CheckResult struct {
+	Status   CheckStatus `json:"status"`
+	Findings []Finding   `json:"findings"`
+}
+
+// newCheckResult derives the status from the worst finding.  A check without findings did not
+// find out anything, so it is Unknown.
+func newCheckResult(findings []Finding) CheckResult {
+	var (
+		result = CheckResult{
+			Status:   CheckOK,
+			Findings: findings,
+		}
+	)
+
+	if len(findings) == 0 {
+		result.Status = CheckUnknown
+	}
+
+	for _, finding := range findings {
+		switch finding.Severity {
+		case SeverityError:
+			result.Status = worseStatus(result.Status, CheckFailed)
+		case SeverityWarning:
+			result.Status = worseStatus(result.Status, CheckWarning)
+		}
+	}
+
+	return result
+}
+
+// unknownCheckResult is for a check which could not look at the cluster at all, for example
+// because an API call failed.
+func unknownCheckResult(name string, format string, a ...interface{}) CheckResult {
+	return CheckResult{
+		Status:   CheckUnknown,
+		Findings: []Finding{newFinding(name, SeverityError, format, a...)},
+	}
+}
+
+func worseStatus(a CheckStatus, b CheckStatus) CheckStatus {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// aggregateStatus is the verdict for the whole cluster: the worst of the results, and Unknown
+// if nothing was checked.
+func aggregateStatus(statuses ...CheckStatus) CheckStatus {
+	var (
+		verdict = CheckOK
+	)
+
+	if len(statuses) == 0 {
+		return CheckUnknown
+	}
+
+	for _, status := range statuses {
+		verdict = worseStatus(verdict, status)
+	}
+
+	return verdict
 }
 
 type NewRunnableObject func(*Services) (RunnableObject, error)
@@ -64,17 +173,26 @@ func BubbleSort(input []RunnableObject) []RunnableObject {
 	return input
 }
 
-func initializeRunnableObjects(services *Services, robjsFuncs []NewRunnableObjectsEntry) ([]RunnableObject, error) {
+// initializeRunnableObjects creates and runs the objects.  An object which could not be created or
+// run is not returned, instead it is reported as an Unknown result.  The entries which did not
+// create and run every one of their objects are returned as failed, without any of their objects,
+// so that they can be tried again.
+func initializeRunnableObjects(services *Services, robjsFuncs []NewRunnableObjectsEntry) ([]RunnableObject, []ObjectReport, []NewRunnableObjectsEntry, error) {
 	var (
 		robjsResult    []RunnableObject
 		errs           []error
 		robjObjectName string
 		robjsCluster   = make([]RunnableObject, 0, 5)
+		robjsEntry     = make([]int, 0, 5)
+		entryFailed    = make([]bool, len(robjsFuncs))
+		robjsRunning   = make([]RunnableObject, 0, 5)
+		unknowns       = make([]ObjectReport, 0)
+		failed         = make([]NewRunnableObjectsEntry, 0)
 		err            error
 	)
 
 	// Loop through New functions which return an array of runnable objects.
-	for _, nroe := range robjsFuncs {
+	for i, nroe := range robjsFuncs {
 		fmt.Fprintf(os.Stderr, "Querying the %s...\n", nroe.Name)
 
 		// Call the New function.
@@ -84,6 +202,8 @@ func initializeRunnableObjects(services *Services, robjsFuncs []NewRunnableObjec
 		for _, err = range errs {
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: Could not create a %s object (%v)!\n", nroe.Name, err)
+				unknowns = append(unknowns, newObjectReport(nroe.Name, unknownCheckResult(nroe.Name, "Could not create a %s object (%v)", nroe.Name, err), 0))
+				entryFailed[i] = true
 			}
 		}
 
@@ -92,31 +212,45 @@ func initializeRunnableObjects(services *Services, robjsFuncs []NewRunnableObjec
 			// What is the runnable object's name?
 			robjObjectName, err = robj.ObjectName()
 			if err != nil {
-				return nil, fmt.Errorf("Error: Could not figure out the objects' name! (%s)\n", err)
+				return nil, nil, nil, fmt.Errorf("Error: Could not figure out the objects' name! (%s)\n", err)
 			}
 
 			// Also make sure the priority is valid.
 			_, err = robj.Priority()
 			if err != nil {
-				return nil, fmt.Errorf("Error: Could not get the priority for %s: %s\n", robjObjectName, err)
+				return nil, nil, nil, fmt.Errorf("Error: Could not get the priority for %s: %s\n", robjObjectName, err)
 			}
 
 			// Append the runnable object.
 			log.Debugf("Appending %s %+v", robjObjectName, robj)
 			robjsCluster = append(robjsCluster, robj)
+			robjsEntry = append(robjsEntry, i)
 		}
 	}
 
 	// Run each object.
-	for _, robj := range robjsCluster {
+	for j, robj := range robjsCluster {
 		robjObjectName, _ = robj.ObjectName()
 		fmt.Fprintf(os.Stderr, "Running the %s...\n", robjObjectName)
 
 		err = robj.Run()
 		if err != nil {
-			return nil, err
+			fmt.Fprintf(os.Stderr, "Error: Could not run the %s (%v)!\n", robjObjectName, err)
+			unknowns = append(unknowns, newObjectReport(robjObjectName, unknownCheckResult(robjObjectName, "Could not run the %s (%v)", robjObjectName, err), 0))
+			entryFailed[robjsEntry[j]] = true
+		}
+	}
+
+	for j, robj := range robjsCluster {
+		if !entryFailed[robjsEntry[j]] {
+			robjsRunning = append(robjsRunning, robj)
+		}
+	}
+	for i, nroe := range robjsFuncs {
+		if entryFailed[i] {
+			failed = append(failed, nroe)
 		}
 	}
 
-	return robjsCluster, nil
+	return robjsRunning, unknowns, failed, nil
 }
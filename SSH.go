@@ -0,0 +1,786 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	sshPort             = "22"
+	sshDialTimeout      = 15 * time.Second
+	sshKeepaliveTimeout = 15 * time.Second
+)
+
+type SSHErrorKind int
+
+const (
+	// The TCP connection or the SSH handshake failed.
+	SSHErrorConnect SSHErrorKind = iota
+	// The server did not accept our key.
+	SSHErrorAuth
+	// The server is not in the known_hosts file.
+	SSHErrorHostKeyUnknown
+	// The server is in the known_hosts file with a different key.
+	SSHErrorHostKeyMismatch
+	// The remote command ran and exited with a non-zero status.
+	SSHErrorCommand
+	// The remote command or the file transfer could not complete.
+	SSHErrorSession
+)
+
+func (k SSHErrorKind) String() string {
+	switch k {
+	case SSHErrorConnect:
+		return "connect"
+	case SSHErrorAuth:
+		return "auth"
+	case SSHErrorHostKeyUnknown:
+		return "unknown host key"
+	case SSHErrorHostKeyMismatch:
+		return "host key mismatch"
+	case SSHErrorCommand:
+		return "command"
+	case SSHErrorSession:
+		return "session"
+	default:
+		return fmt.Sprintf("SSHErrorKind(%d)", int(k))
+	}
+}
+
+// SSHError is returned by every function in this file which talks to a remote server.
+type SSHError struct {
+	Kind    SSHErrorKind
+	Host    string
+	Command string
+	// Only valid when Kind is SSHErrorCommand
+	ExitStatus int
+	Output     string
+	Err        error
+}
+
+func (e *SSHError) Error() string {
+	if e.Kind == SSHErrorCommand {
+		return fmt.Sprintf("ssh %s: %s: exited with status %d: %s", e.Host, e.Command, e.ExitStatus, strings.TrimSpace(e.Output))
+	}
+	if e.Command != "" {
+		return fmt.Sprintf("ssh %s: %s: %s error: %v", e.Host, e.Command, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("ssh %s: %s error: %v", e.Host, e.Kind, e.Err)
+}
+
+func (e *SSHError) Unwrap() error {
+	return e.Err
+}
+
+// sshExitStatus returns the exit status of a remote command which ran but failed.
+func sshExitStatus(err error) (int, bool) {
+	var sshError *SSHError
+
+	if errors.As(err, &sshError) && sshError.Kind == SSHErrorCommand {
+		return sshError.ExitStatus, true
+	}
+
+	return 0, false
+}
+
+// sshTarget identifies a remote user and the key used to log in as them.  It is also the
+// key of the connection cache.
+type sshTarget struct {
+	Host        string
+	Username    string
+	KeyFilename string
+}
+
+func (t sshTarget) String() string {
+	return fmt.Sprintf("%s@%s", t.Username, t.Host)
+}
+
+var (
+	// Empty means ~/.ssh/known_hosts
+	knownHostsFilename = ""
+
+	// Where the commands which are run remotely are echoed
+	sshEcho io.Writer = os.Stdout
+
+	sshClientsLock sync.Mutex
+	sshClients     = make(map[sshTarget]*ssh.Client)
+)
+
+// sshAddress adds the default port unless host already has one, as a test server would.
+func sshAddress(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(host, sshPort)
+}
+
+func getKnownHostsFilename() (string, error) {
+	var (
+		homeDir string
+		err     error
+	)
+
+	if knownHostsFilename != "" {
+		return knownHostsFilename, nil
+	}
+
+	homeDir, err = os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(homeDir, ".ssh", "known_hosts"), nil
+}
+
+// openKnownHosts returns the known_hosts filename, creating an empty file when there is none.
+func openKnownHosts() (string, error) {
+	var (
+		filename string
+		file     *os.File
+		err      error
+	)
+
+	filename, err = getKnownHostsFilename()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(path.Dir(filename), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	file, err = os.OpenFile(filename, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+
+	return filename, nil
+}
+
+// knownHostsCallback verifies the server against the known_hosts file.
+func knownHostsCallback() (ssh.HostKeyCallback, error) {
+	var (
+		filename string
+		callback ssh.HostKeyCallback
+		err      error
+	)
+
+	filename, err = openKnownHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err = knownhosts.New(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		var keyError *knownhosts.KeyError
+
+		err := callback(hostname, remote, key)
+		if errors.As(err, &keyError) {
+			if len(keyError.Want) == 0 {
+				return &SSHError{Kind: SSHErrorHostKeyUnknown, Host: hostname, Err: err}
+			}
+			return &SSHError{Kind: SSHErrorHostKeyMismatch, Host: hostname, Err: err}
+		}
+		return err
+	}, nil
+}
+
+// findServerKnownHosts returns whether there is a known_hosts entry for the address.
+func findServerKnownHosts(address string) (bool, error) {
+	var (
+		callback  ssh.HostKeyCallback
+		publicKey ed25519.PublicKey
+		probeKey  ssh.PublicKey
+		sshError  *SSHError
+		err       error
+	)
+
+	callback, err = knownHostsCallback()
+	if err != nil {
+		return false, err
+	}
+
+	// A key which cannot be in the file, so that any entry for the address shows up as a mismatch.
+	publicKey, _, err = ed25519.GenerateKey(nil)
+	if err != nil {
+		return false, err
+	}
+	probeKey, err = ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return false, err
+	}
+
+	err = callback(sshAddress(address), &net.TCPAddr{IP: net.IPv4zero}, probeKey)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &sshError) && sshError.Kind == SSHErrorHostKeyMismatch:
+		return true, nil
+	case errors.As(err, &sshError) && sshError.Kind == SSHErrorHostKeyUnknown:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// knownHostMatches returns whether a host pattern from a known_hosts line, hashed or not, is the address.
+func knownHostMatches(pattern string, address string) bool {
+	var (
+		fields []string
+		salt   []byte
+		sum    []byte
+		mac    hash.Hash
+		err    error
+	)
+
+	address = knownhosts.Normalize(address)
+
+	if !strings.HasPrefix(pattern, "|1|") {
+		return knownhosts.Normalize(pattern) == address
+	}
+
+	fields = strings.Split(pattern, "|")
+	if len(fields) != 4 {
+		return false
+	}
+	salt, err = base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return false
+	}
+	sum, err = base64.StdEncoding.DecodeString(fields[3])
+	if err != nil {
+		return false
+	}
+
+	mac = hmac.New(sha1.New, salt)
+	mac.Write([]byte(address))
+
+	return hmac.Equal(mac.Sum(nil), sum)
+}
+
+// removeServerKnownHosts removes every known_hosts line for the address.
+func removeServerKnownHosts(address string) error {
+	var (
+		filename string
+		content  []byte
+		scanner  *bufio.Scanner
+		result   bytes.Buffer
+		removed  int
+		err      error
+	)
+
+	filename, err = openKnownHosts()
+	if err != nil {
+		return err
+	}
+
+	content, err = os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	scanner = bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var (
+			line  = scanner.Text()
+			hosts []string
+			found = false
+		)
+
+		_, hosts, _, _, _, err = ssh.ParseKnownHosts([]byte(line))
+		if err == nil {
+			for _, host := range hosts {
+				if knownHostMatches(host, address) {
+					found = true
+				}
+			}
+		}
+
+		if found {
+			removed++
+			continue
+		}
+
+		result.WriteString(line)
+		result.WriteString("\n")
+	}
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("removeServerKnownHosts: removed %d lines for %s", removed, address)
+	if removed == 0 {
+		return nil
+	}
+
+	return os.WriteFile(filename, result.Bytes(), 0600)
+}
+
+// appendServerKnownHosts adds known_hosts lines, such as those returned by keyscanServer.
+func appendServerKnownHosts(lines []byte) error {
+	var (
+		filename string
+		file     *os.File
+		err      error
+	)
+
+	filename, err = openKnownHosts()
+	if err != nil {
+		return err
+	}
+	log.Debugf("appendServerKnownHosts: knownHosts = %s", filename)
+
+	file, err = os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(lines)
+
+	return err
+}
+
+// scanHostKeys is the equivalent of ssh-keyscan.  It asks the server for one key of each common type.
+func scanHostKeys(ctx context.Context, address string) ([]ssh.PublicKey, error) {
+	var (
+		algorithms = []string{
+			ssh.KeyAlgoED25519,
+			ssh.KeyAlgoECDSA256,
+			ssh.KeyAlgoRSASHA512,
+		}
+		errKeyScanned = errors.New("key scanned")
+		keys          []ssh.PublicKey
+		lastErr       error
+	)
+
+	for _, algorithm := range algorithms {
+		var (
+			key    ssh.PublicKey
+			config = &ssh.ClientConfig{
+				User:              "keyscan",
+				HostKeyAlgorithms: []string{algorithm},
+				HostKeyCallback: func(hostname string, remote net.Addr, hostKey ssh.PublicKey) error {
+					key = hostKey
+					return errKeyScanned
+				},
+				Timeout: sshDialTimeout,
+			}
+		)
+
+		_, err := sshHandshake(ctx, address, config)
+		if key == nil {
+			log.Debugf("scanHostKeys: %s %s: %v", address, algorithm, err)
+			lastErr = err
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, &SSHError{Kind: SSHErrorConnect, Host: address, Err: lastErr}
+	}
+
+	return keys, nil
+}
+
+// keyscanServer waits until the server answers and returns its keys as known_hosts lines.
+func keyscanServer(ctx context.Context, ipAddress string, silent bool) ([]byte, error) {
+	var (
+		keys []ssh.PublicKey
+		outb bytes.Buffer
+		err  error
+	)
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Second,
+		Factor:   1.1,
+		Cap:      leftInContext(ctx),
+		Steps:    math.MaxInt32,
+	}
+
+	err = wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+		var (
+			err2 error
+		)
+
+		keys, err2 = scanHostKeys(ctx, ipAddress)
+		if !silent {
+			log.Debugf("keyscanServer: %s: %d keys, err = %v", ipAddress, len(keys), err2)
+		}
+		if err2 != nil {
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		outb.WriteString(knownhosts.Line([]string{ipAddress}, key))
+		outb.WriteString("\n")
+	}
+
+	return outb.Bytes(), nil
+}
+
+// sshHandshake opens a TCP connection which respects ctx and performs the SSH handshake on it.
+func sshHandshake(ctx context.Context, host string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var (
+		address = sshAddress(host)
+		dialer  = net.Dialer{Timeout: config.Timeout}
+		conn    net.Conn
+		sshConn ssh.Conn
+		chans   <-chan ssh.NewChannel
+		reqs    <-chan *ssh.Request
+		err     error
+	)
+
+	conn, err = dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not let a silent server hang the handshake
+	conn.SetDeadline(time.Now().Add(config.Timeout))
+
+	sshConn, chans, reqs, err = ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+func sshDial(ctx context.Context, target sshTarget) (*ssh.Client, error) {
+	var (
+		keyBytes []byte
+		signer   ssh.Signer
+		callback ssh.HostKeyCallback
+		client   *ssh.Client
+		sshError *SSHError
+		err      error
+	)
+
+	keyBytes, err = os.ReadFile(target.KeyFilename)
+	if err != nil {
+		return nil, &SSHError{Kind: SSHErrorAuth, Host: target.Host, Err: err}
+	}
+
+	signer, err = ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, &SSHError{Kind: SSHErrorAuth, Host: target.Host, Err: fmt.Errorf("%s: %w", target.KeyFilename, err)}
+	}
+
+	callback, err = knownHostsCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err = sshHandshake(ctx, target.Host, &ssh.ClientConfig{
+		User:            target.Username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: callback,
+		Timeout:         sshDialTimeout,
+	})
+	switch {
+	case err == nil:
+		return client, nil
+	case errors.As(err, &sshError):
+		return nil, sshError
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return nil, &SSHError{Kind: SSHErrorAuth, Host: target.Host, Err: err}
+	default:
+		return nil, &SSHError{Kind: SSHErrorConnect, Host: target.Host, Err: err}
+	}
+}
+
+// sshConnect returns the cached connection for the target, dialing a new one if needed.  The
+// lock only guards the cache, so that one slow server does not hold up the others.
+func sshConnect(ctx context.Context, target sshTarget) (*ssh.Client, error) {
+	var (
+		client *ssh.Client
+		other  *ssh.Client
+		ok     bool
+		err    error
+	)
+
+	sshClientsLock.Lock()
+	client, ok = sshClients[target]
+	sshClientsLock.Unlock()
+
+	if ok {
+		// Is the cached connection still alive?
+		err = sshKeepalive(client)
+		if err == nil {
+			return client, nil
+		}
+		log.Debugf("sshConnect: dropping dead connection to %s: %v", target, err)
+		client.Close()
+
+		sshClientsLock.Lock()
+		if sshClients[target] == client {
+			delete(sshClients, target)
+		}
+		sshClientsLock.Unlock()
+	}
+
+	client, err = sshDial(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("sshConnect: connected to %s", target)
+
+	sshClientsLock.Lock()
+	defer sshClientsLock.Unlock()
+
+	// Another caller may have connected in the meantime.
+	other, ok = sshClients[target]
+	if ok {
+		client.Close()
+		return other, nil
+	}
+
+	sshClients[target] = client
+
+	return client, nil
+}
+
+// sshKeepalive asks the server for a reply.  A server which does not answer in time has its
+// connection closed, which also ends the request.
+func sshKeepalive(client *ssh.Client) error {
+	var (
+		done  = make(chan error, 1)
+		timer = time.NewTimer(sshKeepaliveTimeout)
+	)
+	defer timer.Stop()
+
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		client.Close()
+		return fmt.Errorf("no keepalive reply after %v", sshKeepaliveTimeout)
+	}
+}
+
+// sshCloseAll closes every cached connection.
+func sshCloseAll() {
+	sshClientsLock.Lock()
+	defer sshClientsLock.Unlock()
+
+	for target, client := range sshClients {
+		client.Close()
+		delete(sshClients, target)
+	}
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,%@+", r))
+	}) < 0 {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// lockedBuffer collects the output of a session.  The SSH library copies stdout and stderr in
+// separate goroutines, so both writers need to share a lock.
+type lockedBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return bytes.Clone(b.buffer.Bytes())
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buffer.String()
+}
+
+// sshRun runs a command on the target and returns its combined output.
+func sshRun(ctx context.Context, target sshTarget, acmdline []string) ([]byte, error) {
+	var (
+		client    *ssh.Client
+		session   *ssh.Session
+		quoted    []string
+		command   string
+		output    lockedBuffer
+		done      = make(chan error, 1)
+		exitError *ssh.ExitError
+		err       error
+	)
+
+	for _, arg := range acmdline {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	command = strings.Join(quoted, " ")
+
+	fmt.Fprintln(sshEcho, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	fmt.Fprintf(sshEcho, "%s: %s\n", target, command)
+
+	client, err = sshConnect(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err = client.NewSession()
+	if err != nil {
+		return nil, &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: err}
+	}
+	defer session.Close()
+
+	session.Stdout = &output
+	session.Stderr = &output
+
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Closing the session ends Run, which waits for the output copies to finish.
+		session.Close()
+		<-done
+		return output.Bytes(), &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: ctx.Err()}
+	}
+
+	switch {
+	case err == nil:
+		return output.Bytes(), nil
+	case errors.As(err, &exitError):
+		return output.Bytes(), &SSHError{
+			Kind:       SSHErrorCommand,
+			Host:       target.Host,
+			Command:    command,
+			ExitStatus: exitError.ExitStatus(),
+			Output:     output.String(),
+			Err:        err,
+		}
+	default:
+		return output.Bytes(), &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Output: output.String(), Err: err}
+	}
+}
+
+// sftpPut copies a local file to the target over SFTP.
+func sftpPut(ctx context.Context, target sshTarget, localFilename string, remoteFilename string) error {
+	var (
+		client     *ssh.Client
+		sftpClient *sftp.Client
+		localFile  *os.File
+		remoteFile *sftp.File
+		command    = fmt.Sprintf("put %s %s", localFilename, remoteFilename)
+		err        error
+	)
+
+	fmt.Fprintln(sshEcho, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	fmt.Fprintf(sshEcho, "%s: %s\n", target, command)
+
+	localFile, err = os.Open(localFilename)
+	if err != nil {
+		return err
+	}
+	defer localFile.Close()
+
+	client, err = sshConnect(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	sftpClient, err = sftp.NewClient(client)
+	if err != nil {
+		return &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: err}
+	}
+	defer sftpClient.Close()
+
+	remoteFile, err = sftpClient.OpenFile(remoteFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: err}
+	}
+
+	_, err = io.Copy(remoteFile, localFile)
+	if err != nil {
+		remoteFile.Close()
+		return &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: err}
+	}
+
+	// The last of the file may only be written when it is closed.
+	err = remoteFile.Close()
+	if err != nil {
+		return &SSHError{Kind: SSHErrorSession, Host: target.Host, Command: command, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,455 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"ls", "ls"},
+		{"/usr/bin/haproxy", "/usr/bin/haproxy"},
+		{"a=b,c:d@e+f%g", "a=b,c:d@e+f%g"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"$HOME", "'$HOME'"},
+		{"it's", `'it'"'"'s'`},
+		{"a;b", "'a;b'"},
+		{"*", "'*'"},
+	}
+
+	for _, test := range tests {
+		if got := shellQuote(test.arg); got != test.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", test.arg, got, test.want)
+		}
+	}
+}
+
+func TestKnownHostMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		address string
+		want    bool
+	}{
+		{"plain", "10.0.0.1", "10.0.0.1", true},
+		{"plain other", "10.0.0.1", "10.0.0.2", false},
+		{"plain default port", "10.0.0.1", "10.0.0.1:22", true},
+		{"plain with port", "[10.0.0.1]:2222", "10.0.0.1:2222", true},
+		{"plain wrong port", "[10.0.0.1]:2222", "10.0.0.1", false},
+		{"hashed", knownhosts.HashHostname("10.0.0.1"), "10.0.0.1", true},
+		{"hashed default port", knownhosts.HashHostname("10.0.0.1"), "10.0.0.1:22", true},
+		{"hashed other", knownhosts.HashHostname("10.0.0.1"), "10.0.0.2", false},
+		{"hashed with port", knownhosts.HashHostname("[10.0.0.1]:2222"), "10.0.0.1:2222", true},
+		{"hashed too few fields", "|1|c2FsdA==", "10.0.0.1", false},
+		{"hashed bad salt", "|1|!!!|c2FsdA==", "10.0.0.1", false},
+		{"hashed bad sum", "|1|c2FsdA==|!!!", "10.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		if got := knownHostMatches(test.pattern, test.address); got != test.want {
+			t.Errorf("%s: knownHostMatches(%q, %q) = %v, want %v", test.name, test.pattern, test.address, got, test.want)
+		}
+	}
+}
+
+func newTestPublicKey(t *testing.T) ssh.PublicKey {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// useTestKnownHosts points the known_hosts functions at a file in a temporary directory.
+func useTestKnownHosts(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "known_hosts")
+
+	err := os.WriteFile(filename, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := knownHostsFilename
+	knownHostsFilename = filename
+	t.Cleanup(func() { knownHostsFilename = saved })
+
+	return filename
+}
+
+func TestKnownHostsFile(t *testing.T) {
+	var (
+		key1 = newTestPublicKey(t)
+		key2 = newTestPublicKey(t)
+	)
+
+	content := strings.Join([]string{
+		"# a comment stays",
+		knownhosts.Line([]string{"10.0.0.1"}, key1),
+		knownhosts.Line([]string{knownhosts.HashHostname("10.0.0.1")}, key2),
+		knownhosts.Line([]string{"10.0.0.2", "bastion.example.com"}, key1),
+		knownhosts.Line([]string{knownhosts.HashHostname("10.0.0.3")}, key2),
+		knownhosts.Line([]string{"[10.0.0.4]:2222"}, key1),
+	}, "\n") + "\n"
+	filename := useTestKnownHosts(t, content)
+
+	for _, test := range []struct {
+		address string
+		want    bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.2", true},
+		{"bastion.example.com", true},
+		{"10.0.0.3", true},
+		{"10.0.0.4:2222", true},
+		{"10.0.0.4", false},
+		{"10.0.0.5", false},
+	} {
+		found, err := findServerKnownHosts(test.address)
+		if err != nil {
+			t.Fatalf("findServerKnownHosts(%s): %v", test.address, err)
+		}
+		if found != test.want {
+			t.Errorf("findServerKnownHosts(%s) = %v, want %v", test.address, found, test.want)
+		}
+	}
+
+	// The plain and the hashed lines for 10.0.0.1 both go, and nothing else does.
+	err := removeServerKnownHosts("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"# a comment stays",
+		knownhosts.Line([]string{"10.0.0.2", "bastion.example.com"}, key1),
+		strings.Split(content, "\n")[4],
+		knownhosts.Line([]string{"[10.0.0.4]:2222"}, key1),
+	}, "\n") + "\n"
+	if string(got) != want {
+		t.Errorf("after removeServerKnownHosts:\n%s\nwant:\n%s", got, want)
+	}
+
+	found, err := findServerKnownHosts("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Errorf("10.0.0.1 is still in known_hosts")
+	}
+
+	// The hashed line for 10.0.0.3 goes too.
+	err = removeServerKnownHosts("10.0.0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = findServerKnownHosts("10.0.0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Errorf("10.0.0.3 is still in known_hosts")
+	}
+
+	// Removing an address which is not there leaves the file alone.
+	before, _ := os.ReadFile(filename)
+	err = removeServerKnownHosts("10.0.0.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(filename)
+	if !bytes.Equal(before, after) {
+		t.Errorf("removing a missing address changed known_hosts")
+	}
+}
+
+// startTestSSHServer runs an SSH server on localhost which runs exec requests with sh and serves
+// the sftp subsystem from the local file system.  It returns a target which is in known_hosts.
+func startTestSSHServer(t *testing.T) sshTarget {
+	_, hostPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, userPrivate, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSigner, err := ssh.NewSignerFromKey(userPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(userPrivate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFilename := filepath.Join(t.TempDir(), "id_ed25519")
+	err = os.WriteFile(keyFilename, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), userSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config)
+		}
+	}()
+
+	address := listener.Addr().String()
+	useTestKnownHosts(t, knownhosts.Line([]string{address}, hostSigner.PublicKey())+"\n")
+	t.Cleanup(sshCloseAll)
+
+	return sshTarget{Host: address, Username: "core", KeyFilename: keyFilename}
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer channel.Close()
+
+			for request := range requests {
+				var payload struct{ Value string }
+
+				ssh.Unmarshal(request.Payload, &payload)
+
+				switch request.Type {
+				case "exec":
+					request.Reply(true, nil)
+
+					cmd := exec.Command("sh", "-c", payload.Value)
+					cmd.Stdout = channel
+					cmd.Stderr = channel.Stderr()
+					status := uint32(0)
+					if err := cmd.Run(); err != nil {
+						var exitError *exec.ExitError
+						status = 255
+						if errors.As(err, &exitError) {
+							status = uint32(exitError.ExitCode())
+						}
+					}
+
+					channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+					return
+				case "subsystem":
+					if payload.Value != "sftp" {
+						request.Reply(false, nil)
+						continue
+					}
+					request.Reply(true, nil)
+
+					server, err := sftp.NewServer(channel)
+					if err != nil {
+						return
+					}
+					server.Serve()
+					return
+				default:
+					request.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+func TestSSHRun(t *testing.T) {
+	target := startTestSSHServer(t)
+	saved := sshEcho
+	sshEcho = io.Discard
+	t.Cleanup(func() { sshEcho = saved })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Both streams are collected, which used to race.
+	output, err := sshRun(ctx, target, []string{"sh", "-c", "for i in 1 2 3 4 5; do echo out$i; echo err$i >&2; done"})
+	if err != nil {
+		t.Fatalf("sshRun: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		for _, want := range []string{"out", "err"} {
+			if !strings.Contains(string(output), want+string(rune('0'+i))) {
+				t.Errorf("output %q does not have %s%d", output, want, i)
+			}
+		}
+	}
+
+	// The arguments are quoted.
+	output, err = sshRun(ctx, target, []string{"echo", "it's $HOME"})
+	if err != nil {
+		t.Fatalf("sshRun: %v", err)
+	}
+	if string(output) != "it's $HOME\n" {
+		t.Errorf("sshRun echo = %q", output)
+	}
+
+	_, err = sshRun(ctx, target, []string{"sh", "-c", "echo failing; exit 3"})
+	status, ok := sshExitStatus(err)
+	if !ok || status != 3 {
+		t.Errorf("sshRun exit 3 = %v, status %d, %v", err, status, ok)
+	}
+
+	short, shortCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer shortCancel()
+	start := time.Now()
+	_, err = sshRun(short, target, []string{"sleep", "5"})
+	var sshError *SSHError
+	if !errors.As(err, &sshError) || sshError.Kind != SSHErrorSession || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("sshRun after the deadline = %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("sshRun took %v after the deadline", time.Since(start))
+	}
+}
+
+func TestSSHConnectErrors(t *testing.T) {
+	target := startTestSSHServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The cached connection is reused.
+	first, err := sshConnect(ctx, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := sshConnect(ctx, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("sshConnect did not reuse the connection")
+	}
+
+	var sshError *SSHError
+
+	useTestKnownHosts(t, "")
+	_, err = sshDial(ctx, target)
+	if !errors.As(err, &sshError) || sshError.Kind != SSHErrorHostKeyUnknown {
+		t.Errorf("sshDial without known_hosts = %v", err)
+	}
+
+	useTestKnownHosts(t, knownhosts.Line([]string{target.Host}, newTestPublicKey(t))+"\n")
+	_, err = sshDial(ctx, target)
+	if !errors.As(err, &sshError) || sshError.Kind != SSHErrorHostKeyMismatch {
+		t.Errorf("sshDial with another host key = %v", err)
+	}
+}
+
+func TestSFTPPut(t *testing.T) {
+	target := startTestSSHServer(t)
+	saved := sshEcho
+	sshEcho = io.Discard
+	t.Cleanup(func() { sshEcho = saved })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	directory := t.TempDir()
+	localFilename := filepath.Join(directory, "local")
+	content := bytes.Repeat([]byte("0123456789abcdef"), 16*1024)
+	err := os.WriteFile(localFilename, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remoteFilename := filepath.Join(directory, "remote")
+	err = sftpPut(ctx, target, localFilename, remoteFilename)
+	if err != nil {
+		t.Fatalf("sftpPut: %v", err)
+	}
+
+	got, err := os.ReadFile(remoteFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("the remote file has %d bytes, want %d", len(got), len(content))
+	}
+
+	err = sftpPut(ctx, target, localFilename, filepath.Join(directory, "missing", "remote"))
+	if err == nil {
+		t.Errorf("sftpPut into a missing directory succeeded")
+	}
+}
@@ -17,10 +17,42 @@ package main
 import (
 	"bufio"
 //	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 //	"io"
 	"io/ioutil"
 	"net"
+	"time"
+)
+
+const (
+	commandDialTimeout = 30 * time.Second
+)
+
+var (
+	// Tests listen on another port
+	commandPort = "8080"
+)
+
+// Version 1 is the original protocol: bare commands without a version, request ID, or a
+// meaningful result, and no reply at all to create-metadata and delete-metadata.  Version 2
+// starts every connection with a hello and answers every command with a CommandResponse.
+const (
+	protocolVersionLegacy = 1
+	protocolVersion       = 2
+)
+
+// The Status of a CommandResponse, borrowed from HTTP.
+const (
+	StatusOK                 = 200
+	StatusBadRequest         = 400
+	StatusUnauthorized       = 401
+	StatusUnknownCommand     = 404
+	StatusFailed             = 500
+	StatusVersionUnsupported = 505
 )
 
 //	buffer := make([]byte, 1024)
@@ -36,36 +68,110 @@ import (
 //	data, err := reader.ReadString('\n')
 // or
 
+// CommandHeader starts every request and response.  Version and RequestID are missing from
+// version 1 messages.
 type CommandHeader struct {
-        Command string `json:"Command"`
+	Command   string `json:"Command"`
+	Version   int    `json:"Version,omitempty"`
+	RequestID string `json:"RequestID,omitempty"`
+}
+
+// CommandHello negotiates the protocol version.  The server answers with the highest version
+// both sides support, or StatusVersionUnsupported.
+type CommandHello struct {
+	CommandHeader
+	MinVersion int `json:"MinVersion"`
+}
+
+// CommandResponse answers every version 2 request.
+type CommandResponse struct {
+	CommandHeader
+	Status  int             `json:"Status"`
+	Message string          `json:"Message,omitempty"`
+	Details json.RawMessage `json:"Details,omitempty"`
 }
 
 type CommandCheckAlive struct {
-	Command  string          `json:"Command"`
+	CommandHeader
+}
+
+// The details of a successful check-alive.
+type CheckAliveDetails struct {
+	Version string `json:"version"`
+	Release string `json:"release"`
 }
 
+// Only sent to version 1 clients.
 type CommandIsAlive struct {
 	Command  string          `json:"Command"`
 	Result   error           `json:"Result"`
 }
 
 type CommandCreateBastion struct {
-	Command    string        `json:"Command"`
+	CommandHeader
 	CloudName  string        `json:"cloudName"`
 	ServerName string        `json:"serverName"`
 	DomainName string        `json:"domainName"`
 }
 
+// Only sent to version 1 clients.
 type CommandBastionCreated struct {
 	Command    string        `json:"Command"`
 	Result     error         `json:"Result"`
 }
 
 type CommandSendMetadata struct {
-	Command  string          `json:"Command"`
+	CommandHeader
 	Metadata CreateMetadata
 }
 
+// RemoteError is a failure reported by the server in a CommandResponse.
+type RemoteError struct {
+	Command   string
+	RequestID string
+	Status    int
+	Message   string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("Error: remote %s failed (request %s, status %d): %s", e.Command, e.RequestID, e.Status, e.Message)
+}
+
+func newRequestID() string {
+	var ab = make([]byte, 8)
+
+	_, err := rand.Read(ab)
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(ab)
+}
+
+func newResponse(header CommandHeader, command string, status int, message string, details interface{}) (*CommandResponse, error) {
+	var (
+		response = &CommandResponse{
+			CommandHeader: CommandHeader{
+				Command:   command,
+				Version:   protocolVersion,
+				RequestID: header.RequestID,
+			},
+			Status:  status,
+			Message: message,
+		}
+		err error
+	)
+
+	if details != nil {
+		response.Details, err = json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
 func sendByteArray(conn net.Conn, ab []byte) (err error) {
 	_, err = conn.Write(ab)
 	if err != nil {
@@ -82,135 +188,272 @@ func sendByteArray(conn net.Conn, ab []byte) (err error) {
 	return
 }
 
-func receiveResponse(conn net.Conn) (response string, err error) {
-	reader := bufio.NewReader(conn)
-
-	response, err = reader.ReadString('\n')
+func sendJSON(conn net.Conn, v interface{}) error {
+	marshalledData, err := json.Marshal(v)
+	if err != nil {
+		log.Debugf("sendJSON: json.Marshal returns %v", err)
+		return err
+	}
+	log.Debugf("sendJSON: marshalledData = %v", string(marshalledData))
 
-	return
+	return sendByteArray(conn, marshalledData)
 }
 
-func sendCheckAlive(serverIP string) error {
+// dialCommandServer connects to the watch-installation listener over mutual TLS.
+func dialCommandServer(serverIP string, certDirectory string) (net.Conn, error) {
 	var (
-		cmd            CommandCheckAlive
-		marshalledData []byte
-		response       string
-		err            error
+		tlsConfig *tls.Config
+		conn      *tls.Conn
+		err       error
 	)
 
-	cmd = CommandCheckAlive{
-		Command: "check-alive",
+	tlsConfig, err = newClientTLSConfig(certDirectory)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Debugf("sendCheckAlive: serverIP = %s", serverIP)
-
 	// Avoid: address format "%s:%s" does not work with IPv6
-	// Connect to the server
-	conn, err := net.Dial("tcp", net.JoinHostPort(serverIP, "8080"))
+	conn, err = tls.DialWithDialer(&net.Dialer{Timeout: commandDialTimeout}, "tcp", net.JoinHostPort(serverIP, commandPort), tlsConfig)
 	if err != nil {
-		log.Debugf("sendCheckAlive: net.Dial returns %v", err)
-		return err
+		return nil, err
 	}
 
-	// Close the connection when we're done
-	defer conn.Close()
+	return conn, nil
+}
 
-	marshalledData, err = json.Marshal(cmd)
+// commandConn is a client connection with its negotiated protocol version.
+type commandConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	version int
+}
+
+// openCommandConn connects and negotiates the protocol version.  Only a server which answers
+// the hello like version 1 does, without a Status, or which says that it does not know the hello
+// or the version, is spoken to in version 1, over a new connection.  Any other failure is
+// returned, since falling back would silently lose the request IDs and the errors.
+func openCommandConn(serverIP string, certDirectory string) (*commandConn, error) {
+	var (
+		cc       *commandConn
+		conn     net.Conn
+		hello    CommandHello
+		data     string
+		response CommandResponse
+		err      error
+	)
+
+	conn, err = dialCommandServer(serverIP, certDirectory)
 	if err != nil {
-		log.Debugf("sendCheckAlive: json.Marshal returns %v", err)
-		return err
+		log.Debugf("openCommandConn: dialCommandServer returns %v", err)
+		return nil, err
+	}
+
+	cc = &commandConn{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		version: protocolVersion,
 	}
-	log.Debugf("sendAlive: marshalledData = %v", string(marshalledData))
 
-	// Send the command to the server
-	err = sendByteArray(conn, marshalledData)
+	hello = CommandHello{
+		CommandHeader: CommandHeader{
+			Command:   "hello",
+			Version:   protocolVersion,
+			RequestID: newRequestID(),
+		},
+		MinVersion: protocolVersionLegacy,
+	}
+
+	err = sendJSON(conn, hello)
+	if err == nil {
+		data, err = cc.reader.ReadString('\n')
+	}
 	if err != nil {
-		return err
+		log.Debugf("openCommandConn: hello returns %v", err)
+		conn.Close()
+		return nil, fmt.Errorf("Error: The server did not answer the hello: %v", err)
 	}
 
-	response, err = receiveResponse(conn)
+	err = json.Unmarshal([]byte(data), &response)
 	if err != nil {
-		log.Debugf("sendCheckAlive: receiveResponse returns %v", err)
-		// if err != io.EOF {
-		return err
+		conn.Close()
+		return nil, fmt.Errorf("Error: Could not parse the hello response: %v", err)
+	}
+	log.Debugf("openCommandConn: hello response = %+v", response)
+
+	switch response.Status {
+	case 0, StatusUnknownCommand, StatusVersionUnsupported:
+		log.Debugf("openCommandConn: hello answered with status %d, falling back to version %d", response.Status, protocolVersionLegacy)
+		conn.Close()
+
+		conn, err = dialCommandServer(serverIP, certDirectory)
+		if err != nil {
+			return nil, err
+		}
+
+		return &commandConn{
+			conn:    conn,
+			reader:  bufio.NewReader(conn),
+			version: protocolVersionLegacy,
+		}, nil
+	case StatusOK:
+	default:
+		conn.Close()
+		return nil, &RemoteError{
+			Command:   hello.Command,
+			RequestID: response.RequestID,
+			Status:    response.Status,
+			Message:   response.Message,
+		}
 	}
-	log.Debugf("sendCheckAlive: read: %s", response)
-	log.Debugf("sendCheckAlive: Done!")
 
-	return nil
+	cc.version = response.Version
+
+	return cc, nil
+}
+
+func (cc *commandConn) Close() error {
+	return cc.conn.Close()
 }
 
-func sendCreateBastion(serverIP string, cloudName string, serverName string, domainName string) error {
+// roundTrip sends a request and, for version 2, waits for its response.  A failure reported
+// by the server is returned as a *RemoteError.  Version 1 servers do not report anything, so
+// roundTrip returns a nil response for them.
+func (cc *commandConn) roundTrip(header *CommandHeader, request interface{}, waitLegacy bool) (*CommandResponse, error) {
 	var (
-		cmd            CommandCreateBastion
-		marshalledData []byte
-		response       string
-		err            error
+		data     string
+		response CommandResponse
+		err      error
 	)
 
-	cmd = CommandCreateBastion{
-		Command:    "create-bastion",
-		CloudName:  cloudName,
-		ServerName: serverName,
-		DomainName: domainName,
+	if cc.version >= protocolVersion {
+		header.Version = cc.version
+		header.RequestID = newRequestID()
 	}
 
-	log.Debugf("sendCreateBastion: serverIP = %s", serverIP)
-
-	// Avoid: address format "%s:%s" does not work with IPv6
-	// Connect to the server
-	conn, err := net.Dial("tcp", net.JoinHostPort(serverIP, "8080"))
+	err = sendJSON(cc.conn, request)
 	if err != nil {
-		log.Debugf("sendCreateBastion: net.Dial returns %v", err)
-		return err
+		return nil, err
 	}
 
-	// Close the connection when we're done
-	defer conn.Close()
+	if cc.version < protocolVersion {
+		fmt.Printf("Warning: the server only speaks protocol version %d and cannot report errors\n", cc.version)
+		if waitLegacy {
+			data, err = cc.reader.ReadString('\n')
+			log.Debugf("roundTrip: legacy response = %s", data)
+		}
+		return nil, err
+	}
 
-	marshalledData, err = json.Marshal(cmd)
+	data, err = cc.reader.ReadString('\n')
 	if err != nil {
-		log.Debugf("sendCreateBastion: json.Marshal returns %v", err)
-		return err
+		log.Debugf("roundTrip: ReadString returns %v", err)
+		return nil, err
 	}
-	log.Debugf("sendCreateBastion: marshalledData = %v", string(marshalledData))
 
-	// Send the command to the server
-	err = sendByteArray(conn, marshalledData)
+	err = json.Unmarshal([]byte(data), &response)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse the %s response: %v", header.Command, err)
+	}
+	log.Debugf("roundTrip: response = %+v", response)
+
+	if response.RequestID != header.RequestID {
+		return nil, fmt.Errorf("Error: %s response is for request %s, not %s", header.Command, response.RequestID, header.RequestID)
+	}
+	if response.Status != StatusOK {
+		return &response, &RemoteError{
+			Command:   header.Command,
+			RequestID: response.RequestID,
+			Status:    response.Status,
+			Message:   response.Message,
+		}
+	}
+
+	return &response, nil
+}
+
+func sendCheckAlive(serverIP string, certDirectory string) error {
+	var (
+		cc       *commandConn
+		cmd      CommandCheckAlive
+		response *CommandResponse
+		details  CheckAliveDetails
+		err      error
+	)
+
+	log.Debugf("sendCheckAlive: serverIP = %s", serverIP)
+
+	// Connect to the server
+	cc, err = openCommandConn(serverIP, certDirectory)
 	if err != nil {
 		return err
 	}
 
-	response, err = receiveResponse(conn)
+	// Close the connection when we're done
+	defer cc.Close()
+
+	cmd.Command = "check-alive"
+
+	response, err = cc.roundTrip(&cmd.CommandHeader, &cmd, true)
 	if err != nil {
-		log.Debugf("sendCreateBastion: receiveResponse returns %v", err)
-		// if err != io.EOF {
+		log.Debugf("sendCheckAlive: roundTrip returns %v", err)
 		return err
 	}
-	log.Debugf("sendCreateBastion: read: %s", response)
-	log.Debugf("sendCreateBastion: Done!")
+
+	if response != nil && len(response.Details) != 0 {
+		err = json.Unmarshal(response.Details, &details)
+		if err == nil {
+			fmt.Printf("Server is alive (version %s, release %s)\n", details.Version, details.Release)
+		}
+	}
+	log.Debugf("sendCheckAlive: Done!")
 
 	return nil
 }
 
-func sendMetadata(metadataFile string, serverIP string, shouldCreateMetadata bool) error {
+func sendCreateBastion(serverIP string, certDirectory string, cloudName string, serverName string, domainName string) error {
 	var (
-		content        []byte
-		cmd            CommandSendMetadata
-		marshalledData []byte
-		err            error
+		cc  *commandConn
+		cmd CommandCreateBastion
+		err error
 	)
 
-	// Avoid: address format "%s:%s" does not work with IPv6
+	log.Debugf("sendCreateBastion: serverIP = %s", serverIP)
+
 	// Connect to the server
-	conn, err := net.Dial("tcp", net.JoinHostPort(serverIP, "8080"))
+	cc, err = openCommandConn(serverIP, certDirectory)
 	if err != nil {
-		log.Debugf("sendMetadata: net.Dial return %v", err)
 		return err
 	}
 
 	// Close the connection when we're done
-	defer conn.Close()
+	defer cc.Close()
+
+	cmd = CommandCreateBastion{
+		CommandHeader: CommandHeader{
+			Command: "create-bastion",
+		},
+		CloudName:  cloudName,
+		ServerName: serverName,
+		DomainName: domainName,
+	}
+
+	_, err = cc.roundTrip(&cmd.CommandHeader, &cmd, true)
+	if err != nil {
+		log.Debugf("sendCreateBastion: roundTrip returns %v", err)
+		return err
+	}
+	log.Debugf("sendCreateBastion: Done!")
+
+	return nil
+}
+
+func sendMetadata(metadataFile string, serverIP string, certDirectory string, shouldCreateMetadata bool) error {
+	var (
+		content []byte
+		cc      *commandConn
+		cmd     CommandSendMetadata
+		err     error
+	)
 
 	// Read metadata.json into a buffer
 	content, err = ioutil.ReadFile(metadataFile)
@@ -233,19 +476,20 @@ func sendMetadata(metadataFile string, serverIP string, shouldCreateMetadata boo
 	}
 	log.Debugf("sendMetadata: cmd = %+v", cmd)
 
-	marshalledData, err = json.Marshal(cmd)
+	// Connect to the server
+	cc, err = openCommandConn(serverIP, certDirectory)
 	if err != nil {
-		log.Debugf("sendMetadata: json.Marshal return %v", err)
 		return err
 	}
-	log.Debugf("sendMetadata: marshalledData = %v", string(marshalledData))
 
-	// Send the command to the server
-	err = sendByteArray(conn, marshalledData)
+	// Close the connection when we're done
+	defer cc.Close()
+
+	_, err = cc.roundTrip(&cmd.CommandHeader, &cmd, false)
 	if err != nil {
+		log.Debugf("sendMetadata: roundTrip returns %v", err)
 		return err
 	}
-
 	log.Debugf("sendMetadata: Done!")
 
 	return nil
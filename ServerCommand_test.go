@@ -0,0 +1,126 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// startTestCommandServer listens on a free port with the certificates in certDirectory, and
+// answers the hello of every connection with answer.  An empty answer closes the connection.
+func startTestCommandServer(t *testing.T, certDirectory string, answer string) {
+	var (
+		tlsConfig *tls.Config
+		listener  net.Listener
+		err       error
+	)
+
+	tlsConfig, err = newServerTLSConfig(certDirectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	savedPort := commandPort
+	_, commandPort, _ = net.SplitHostPort(listener.Addr().String())
+	t.Cleanup(func() { commandPort = savedPort })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				_, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || answer == "" {
+					return
+				}
+				conn.Write([]byte(answer + "\n"))
+
+				// Wait for the client to close the connection.
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				conn.Read(make([]byte, 1))
+			}()
+		}
+	}()
+}
+
+func TestOpenCommandConn(t *testing.T) {
+	var (
+		certDirectory = t.TempDir()
+	)
+
+	err := generateCertificates(certDirectory, []string{"127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		answer  string
+		version int
+		status  int
+		fails   bool
+	}{
+		{name: "version 2", answer: `{"Command":"hello","Version":2,"RequestID":"r","Status":200}`, version: protocolVersion},
+		{name: "version 1 reply", answer: `{"Command":"is-alive","Result":null}`, version: protocolVersionLegacy},
+		{name: "unknown command", answer: `{"Command":"error","Version":2,"RequestID":"r","Status":404}`, version: protocolVersionLegacy},
+		{name: "unsupported version", answer: `{"Command":"hello","RequestID":"r","Status":505}`, version: protocolVersionLegacy},
+		{name: "closed", answer: "", fails: true},
+		{name: "not JSON", answer: "garbage", fails: true},
+		{name: "failed", answer: `{"Command":"hello","Version":2,"RequestID":"r","Status":500,"Message":"busy"}`, status: StatusFailed, fails: true},
+	}
+
+	for _, test := range tests {
+		startTestCommandServer(t, certDirectory, test.answer)
+
+		cc, err := openCommandConn("127.0.0.1", certDirectory)
+		if test.fails {
+			if err == nil {
+				cc.Close()
+				t.Errorf("%s: openCommandConn succeeded with version %d", test.name, cc.version)
+				continue
+			}
+
+			var remoteError *RemoteError
+			if errors.As(err, &remoteError) != (test.status != 0) || (test.status != 0 && remoteError.Status != test.status) {
+				t.Errorf("%s: got error %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if cc.version != test.version {
+			t.Errorf("%s: got version %d, want %d", test.name, cc.version, test.version)
+		}
+		cc.Close()
+	}
+}
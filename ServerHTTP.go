@@ -0,0 +1,233 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// A metadata.json is a few kilobytes
+	maxRequestBody = 1 << 20
+)
+
+// The details of GET /v1/healthz
+type HealthzDetails struct {
+	Version       string    `json:"version"`
+	Release       string    `json:"release"`
+	LastReconcile time.Time `json:"lastReconcile"`
+}
+
+// The details of POST /v1/reconcile
+type ReconcileDetails struct {
+	Queued bool `json:"queued"`
+}
+
+// listenForHTTP serves the REST API and the Prometheus /metrics.  Everything except /v1/healthz
+// needs a client certificate from server-certs, the same as the port 8080 listener.
+func listenForHTTP(address string, cloud string, tlsConfig *tls.Config, state *watchState) error {
+	var (
+		httpConfig *tls.Config
+		server     *http.Server
+	)
+
+	log.Debugf("listenForHTTP: address = %s", address)
+
+	// Ask for a client certificate but let requireClientCert decide
+	httpConfig = tlsConfig.Clone()
+	httpConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	server = &http.Server{
+		Addr:              address,
+		Handler:           newHTTPHandler(cloud, state),
+		TLSConfig:         httpConfig,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	err := server.ListenAndServeTLS("", "")
+	log.Errorf("listenForHTTP: ListenAndServeTLS returns %v", err)
+
+	return err
+}
+
+// newHTTPHandler routes the REST API and /metrics.
+func newHTTPHandler(cloud string, state *watchState) http.Handler {
+	var (
+		mux = http.NewServeMux()
+	)
+
+	mux.HandleFunc("GET /v1/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHTTPResult(w, r, "healthz", StatusFailed, nil, HealthzDetails{
+			Version:       version,
+			Release:       release,
+			LastReconcile: state.getLastReconcile(),
+		})
+	})
+	mux.Handle("GET /metrics", requireClientCert(promhttp.Handler().ServeHTTP))
+	mux.Handle("GET /v1/clusters", requireClientCert(func(w http.ResponseWriter, r *http.Request) {
+		writeHTTPResult(w, r, "clusters", StatusFailed, nil, state.getBastionInformations())
+	}))
+	mux.Handle("POST /v1/metadata", requireClientCert(func(w http.ResponseWriter, r *http.Request) {
+		handleHTTPMetadata(w, r, true)
+	}))
+	mux.Handle("DELETE /v1/metadata", requireClientCert(func(w http.ResponseWriter, r *http.Request) {
+		handleHTTPMetadata(w, r, false)
+	}))
+	mux.Handle("POST /v1/bastions", requireClientCert(func(w http.ResponseWriter, r *http.Request) {
+		handleHTTPBastions(w, r, cloud)
+	}))
+	mux.Handle("POST /v1/reconcile", requireClientCert(func(w http.ResponseWriter, r *http.Request) {
+		state.requestReconcile()
+		writeHTTPResult(w, r, "reconcile", StatusFailed, nil, ReconcileDetails{Queued: true})
+	}))
+
+	return mux
+}
+
+func requireClientCert(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			writeHTTPResult(w, r, "error", StatusUnauthorized, fmt.Errorf("a client certificate from server-certs is required"), nil)
+			return
+		}
+		log.Debugf("requireClientCert: %s %s from %s", r.Method, r.URL.Path, r.TLS.VerifiedChains[0][0].Subject)
+
+		handler(w, r)
+	})
+}
+
+// writeHTTPResult answers with the same CommandResponse as the port 8080 listener, and its
+// Status is also the HTTP status.  A nil result is StatusOK.
+func writeHTTPResult(w http.ResponseWriter, r *http.Request, command string, failedStatus int, result error, details interface{}) {
+	var (
+		header = CommandHeader{
+			RequestID: r.Header.Get("X-Request-ID"),
+		}
+		response *CommandResponse
+		err      error
+	)
+
+	if header.RequestID == "" {
+		header.RequestID = newRequestID()
+	}
+
+	if result == nil {
+		response, err = newResponse(header, command, StatusOK, "", details)
+	} else {
+		response, err = newResponse(header, command, failedStatus, result.Error(), nil)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Debugf("writeHTTPResult: %s %s: %d %s", r.Method, r.URL.Path, response.Status, response.Message)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-ID", response.RequestID)
+	w.WriteHeader(response.Status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleHTTPMetadata takes the contents of a metadata.json file, the same as send-metadata.
+func handleHTTPMetadata(w http.ResponseWriter, r *http.Request, shouldCreate bool) {
+	var (
+		command        = "metadata-created"
+		body           []byte
+		cmd            CommandSendMetadata
+		marshalledData []byte
+		errChan        = make(chan error)
+		err            error
+	)
+
+	if !shouldCreate {
+		command = "metadata-deleted"
+	}
+
+	body, err = io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err == nil {
+		err = json.Unmarshal(body, &cmd.Metadata)
+	}
+	if err == nil {
+		err = validateInfraID(cmd.Metadata.InfraID)
+	}
+	if err != nil {
+		writeHTTPResult(w, r, command, StatusBadRequest, err, nil)
+		return
+	}
+
+	if shouldCreate {
+		cmd.Command = "create-metadata"
+	} else {
+		cmd.Command = "delete-metadata"
+	}
+
+	marshalledData, err = json.Marshal(cmd)
+	if err != nil {
+		writeHTTPResult(w, r, command, StatusFailed, err, nil)
+		return
+	}
+
+	go handleCreateMetadata(string(marshalledData), shouldCreate, errChan)
+	err = <-errChan
+	log.Debugf("handleHTTPMetadata: result from handleCreateMetadata is %v", err)
+
+	writeHTTPResult(w, r, command, metadataStatus(err), err, nil)
+}
+
+// handleHTTPBastions takes the same JSON as CommandCreateBastion, for example
+// {"serverName": "...", "domainName": "..."}
+func handleHTTPBastions(w http.ResponseWriter, r *http.Request, cloud string) {
+	var (
+		command        = "bastion-created"
+		body           []byte
+		cmd            CommandCreateBastion
+		marshalledData []byte
+		errChan        = make(chan error)
+		err            error
+	)
+
+	body, err = io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err == nil {
+		err = json.Unmarshal(body, &cmd)
+	}
+	if err == nil && cmd.ServerName == "" {
+		err = fmt.Errorf("serverName is missing")
+	}
+	if err != nil {
+		writeHTTPResult(w, r, command, StatusBadRequest, err, nil)
+		return
+	}
+
+	cmd.Command = "create-bastion"
+
+	marshalledData, err = json.Marshal(cmd)
+	if err != nil {
+		writeHTTPResult(w, r, command, StatusFailed, err, nil)
+		return
+	}
+
+	go handleCreateBastion(string(marshalledData), cloud, errChan)
+	err = <-errChan
+	log.Debugf("handleHTTPBastions: result from handleCreateBastion is %v", err)
+
+	writeHTTPResult(w, r, command, StatusFailed, err, nil)
+}
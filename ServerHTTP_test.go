@@ -0,0 +1,189 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withClientCert makes every request look like it came with a verified client certificate.
+func withClientCert(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// TestHTTPClustersWhileUpdating is meant for go test -race.  The watch loop updates its slice in
+// place, the same as updateBastionInformations, while GET /v1/clusters reads the state.
+func TestHTTPClustersWhileUpdating(t *testing.T) {
+	var (
+		state               = newWatchState()
+		server              = httptest.NewServer(withClientCert(newHTTPHandler("cloud", state)))
+		bastionInformations = make([]bastionInformation, 3)
+		done                = make(chan struct{})
+		wg                  sync.WaitGroup
+	)
+	defer server.Close()
+
+	for i := range bastionInformations {
+		bastionInformations[i].InfraID = fmt.Sprintf("cluster%d-abc12", i)
+	}
+	state.setBastionInformations(bastionInformations)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for n := 0; ; n++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			for i := range bastionInformations {
+				bastionInformations[i].Valid = n%2 == 0
+				bastionInformations[i].NumVMs = n
+			}
+			state.setBastionInformations(bastionInformations)
+		}
+	}()
+
+	for n := 0; n < 50; n++ {
+		var (
+			response *http.Response
+			result   CommandResponse
+			clusters []bastionInformation
+			err      error
+		)
+
+		response, err = http.Get(server.URL + "/v1/clusters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = json.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != StatusOK {
+			t.Fatalf("GET /v1/clusters returned %d: %s", result.Status, result.Message)
+		}
+
+		err = json.Unmarshal(result.Details, &clusters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(clusters) != len(bastionInformations) {
+			t.Fatalf("GET /v1/clusters returned %d clusters, want %d", len(clusters), len(bastionInformations))
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+func TestValidateInfraID(t *testing.T) {
+	tests := map[string]bool{
+		"test-abc12":            true,
+		"a":                     true,
+		"":                      false,
+		"..":                    false,
+		"../../etc/x":           false,
+		"test/abc12":            false,
+		"/tmp":                  false,
+		"test-":                 false,
+		"Test-abc12":            false,
+		"test_abc12":            false,
+		strings.Repeat("a", 64): false,
+	}
+
+	for infraID, valid := range tests {
+		err := validateInfraID(infraID)
+		if (err == nil) != valid {
+			t.Errorf("validateInfraID(%q) returns %v", infraID, err)
+		}
+		if err != nil && !errors.Is(err, errInvalidInfraID) {
+			t.Errorf("validateInfraID(%q) returns %v, which is not errInvalidInfraID", infraID, err)
+		}
+	}
+}
+
+func TestHTTPMetadata(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		server    = httptest.NewServer(withClientCert(newHTTPHandler("cloud", newWatchState())))
+	)
+	defer server.Close()
+
+	err := os.Mkdir(filepath.Join(directory, "daemon"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(directory, "daemon"))
+
+	send := func(method string, body string) int {
+		request, err := http.NewRequest(method, server.URL+"/v1/metadata", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	for _, infraID := range []string{"../escaped", "../../etc/x", "a/b", ""} {
+		body := fmt.Sprintf(`{"clusterName": "test", "infraID": %q}`, infraID)
+
+		if status := send(http.MethodPost, body); status != http.StatusBadRequest {
+			t.Errorf("POST with infraID %q returned %d", infraID, status)
+		}
+		if status := send(http.MethodDelete, body); status != http.StatusBadRequest {
+			t.Errorf("DELETE with infraID %q returned %d", infraID, status)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(directory, "escaped")); err == nil {
+		t.Errorf("POST created a directory outside of the daemon's directory")
+	}
+
+	body := `{"clusterName": "test", "infraID": "test-abc12"}`
+	if status := send(http.MethodPost, body); status != http.StatusOK {
+		t.Fatalf("POST returned %d", status)
+	}
+	if _, err := os.Stat(filepath.Join("test-abc12", "metadata.json")); err != nil {
+		t.Errorf("POST did not write the metadata: %v", err)
+	}
+	if status := send(http.MethodDelete, body); status != http.StatusOK {
+		t.Errorf("DELETE returned %d", status)
+	}
+	if _, err := os.Stat("test-abc12"); err == nil {
+		t.Errorf("DELETE did not remove the directory")
+	}
+}
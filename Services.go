@@ -52,12 +52,18 @@ type Services struct {
 	//
 	metadata *Metadata
 
+	// The directory with metadata.json and the files the installer created
+	installDirectory string
+
 	//
 	baseDomain string
 
 	//
 	cisInstanceCRN string
 
+	// The DNS server, as host:port, to resolve the cluster names with.  Empty means /etc/resolv.conf
+	resolver string
+
 	// type ResourceControllerV2
 	controllerSvc *resourcecontrollerv2.ResourceControllerV2
 
@@ -80,7 +86,7 @@ type User struct {
 	generation int
 }
 
-func NewServices(metadata *Metadata, apiKey string, kubeConfig string, cloud string, bastionUsername string, installerRsa string, baseDomain string, cisInstanceCRN string) (*Services, error) {
+func NewServices(metadata *Metadata, installDirectory string, apiKey string, kubeConfig string, cloud string, bastionUsername string, installerRsa string, baseDomain string, cisInstanceCRN string, resolver string) (*Services, error) {
 	var (
 		ctx             context.Context
 		controllerSvc   *resourcecontrollerv2.ResourceControllerV2
@@ -113,18 +119,20 @@ func NewServices(metadata *Metadata, apiKey string, kubeConfig string, cloud str
 	}
 
 	services = &Services{
-		apiKey:          apiKey,
-		kubeConfig:      kubeConfig,
-		cloud:           cloud,
-		bastionUsername: bastionUsername,
-		controllerSvc:   controllerSvc,
-		installerRsa:    installerRsa,
-		metadata:        metadata,
-		baseDomain:      baseDomain,
-		cisInstanceCRN:  cisInstanceCRN,
-		bxSession:       bxSession,
-		user:            user,
-		ctx:             ctx,
+		apiKey:           apiKey,
+		kubeConfig:       kubeConfig,
+		cloud:            cloud,
+		bastionUsername:  bastionUsername,
+		controllerSvc:    controllerSvc,
+		installerRsa:     installerRsa,
+		metadata:         metadata,
+		installDirectory: installDirectory,
+		baseDomain:       baseDomain,
+		cisInstanceCRN:   cisInstanceCRN,
+		resolver:         resolver,
+		bxSession:        bxSession,
+		user:             user,
+		ctx:              ctx,
 	}
 
 	return services, nil
@@ -154,10 +162,18 @@ func (svc *Services) GetMetadata() *Metadata {
 	return svc.metadata
 }
 
+func (svc *Services) GetInstallDirectory() string {
+	return svc.installDirectory
+}
+
 func (svc *Services) GetBaseDomain() string {
 	return svc.baseDomain
 }
 
+func (svc *Services) GetResolver() string {
+	return svc.resolver
+}
+
 func (svc *Services) GetCISInstanceCRN() string {
 	return svc.cisInstanceCRN
 }
@@ -0,0 +1,259 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The files server-certs writes into the certificate directory.  The listener uses the server
+// pair, the clients (check-alive, create-bastion and send-metadata) use the client pair, and
+// both only trust peers signed by the CA.
+const (
+	caCertFilename     = "ca.crt"
+	caKeyFilename      = "ca.key"
+	serverCertFilename = "server.crt"
+	serverKeyFilename  = "server.key"
+	clientCertFilename = "client.crt"
+	clientKeyFilename  = "client.key"
+)
+
+func defaultCertDirectory() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(configDir, "powervc-tool", "certs")
+}
+
+// addCertDirectoryFlag adds the --certDirectory flag to a command which talks over port 8080.
+func addCertDirectoryFlag(flags *flag.FlagSet) *string {
+	return flags.String("certDirectory", defaultCertDirectory(), "The directory with the certificates created by server-certs")
+}
+
+func loadCertPool(certDirectory string) (*x509.CertPool, error) {
+	var (
+		content []byte
+		pool    = x509.NewCertPool()
+		err     error
+	)
+
+	content, err = os.ReadFile(filepath.Join(certDirectory, caCertFilename))
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not read the CA certificate (run server-certs first): %v", err)
+	}
+
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("Error: No certificates found in %s", filepath.Join(certDirectory, caCertFilename))
+	}
+
+	return pool, nil
+}
+
+// newServerTLSConfig only accepts clients with a certificate signed by our CA.
+func newServerTLSConfig(certDirectory string) (*tls.Config, error) {
+	var (
+		pool *x509.CertPool
+		cert tls.Certificate
+		err  error
+	)
+
+	pool, err = loadCertPool(certDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err = tls.LoadX509KeyPair(filepath.Join(certDirectory, serverCertFilename), filepath.Join(certDirectory, serverKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not load the server certificate: %v", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+func newClientTLSConfig(certDirectory string) (*tls.Config, error) {
+	var (
+		pool *x509.CertPool
+		cert tls.Certificate
+		err  error
+	)
+
+	pool, err = loadCertPool(certDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err = tls.LoadX509KeyPair(filepath.Join(certDirectory, clientCertFilename), filepath.Join(certDirectory, clientKeyFilename))
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not load the client certificate: %v", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// newCertificate creates a key and a certificate signed by parent.  A nil parent creates a
+// self-signed CA.
+func newCertificate(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	var (
+		key          *ecdsa.PrivateKey
+		serialNumber *big.Int
+		der          []byte
+		cert         *x509.Certificate
+		err          error
+	)
+
+	key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serialNumber
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	der, err = x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+func writeCertificate(certDirectory string, certFilename string, keyFilename string, cert *x509.Certificate, key *ecdsa.PrivateKey) error {
+	var (
+		keyDer []byte
+		err    error
+	)
+
+	err = os.WriteFile(filepath.Join(certDirectory, certFilename), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err = x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(certDirectory, keyFilename), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+// generateCertificates creates a CA, a server certificate valid for hosts, and a client certificate.
+func generateCertificates(certDirectory string, hosts []string, validity time.Duration) error {
+	var (
+		notBefore  = time.Now().Add(-5 * time.Minute)
+		notAfter   = notBefore.Add(validity)
+		caCert     *x509.Certificate
+		caKey      *ecdsa.PrivateKey
+		serverTmpl *x509.Certificate
+		cert       *x509.Certificate
+		key        *ecdsa.PrivateKey
+		err        error
+	)
+
+	err = os.MkdirAll(certDirectory, 0700)
+	if err != nil {
+		return err
+	}
+
+	caCert, caKey, err = newCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "PowerVC-Tool CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+	err = writeCertificate(certDirectory, caCertFilename, caKeyFilename, caCert, caKey)
+	if err != nil {
+		return err
+	}
+
+	serverTmpl = &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "PowerVC-Tool server"},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTmpl.IPAddresses = append(serverTmpl.IPAddresses, ip)
+		} else {
+			serverTmpl.DNSNames = append(serverTmpl.DNSNames, host)
+		}
+	}
+
+	cert, key, err = newCertificate(serverTmpl, caCert, caKey)
+	if err != nil {
+		return err
+	}
+	err = writeCertificate(certDirectory, serverCertFilename, serverKeyFilename, cert, key)
+	if err != nil {
+		return err
+	}
+
+	cert, key, err = newCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "PowerVC-Tool client"},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+	if err != nil {
+		return err
+	}
+
+	return writeCertificate(certDirectory, clientCertFilename, clientKeyFilename, cert, key)
+}
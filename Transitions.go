@@ -0,0 +1,140 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	noState = "(none)"
+)
+
+// Transition is something in the cluster which changed between two reports, for example
+// "master-1 ssh DEAD→ALIVE".
+type Transition struct {
+	Time    time.Time
+	Subject string
+	From    string
+	To      string
+}
+
+func (t Transition) String() string {
+	return fmt.Sprintf("%s %s→%s", t.Subject, t.From, t.To)
+}
+
+// reportStates flattens a report into subject → state.  A finding with fields contributes one
+// subject per field, for example "master-1 ssh", otherwise its severity is its state.
+func reportStates(report *Report) map[string]string {
+	var (
+		states = make(map[string]string)
+	)
+
+	states["cluster"] = report.Verdict.String()
+
+	for _, objectReport := range report.Objects {
+		states[objectReport.Name+" check"] = objectReport.Status.String()
+
+		for _, finding := range objectReport.Findings {
+			if len(finding.Fields) == 0 {
+				states[finding.Name] = string(finding.Severity)
+				continue
+			}
+
+			for key, value := range finding.Fields {
+				states[finding.Name+" "+key] = value
+			}
+		}
+	}
+
+	return states
+}
+
+// diffStates returns what changed from previous to current, sorted by subject.
+func diffStates(previous map[string]string, current map[string]string, now time.Time) []Transition {
+	var (
+		subjects    []string
+		transitions []Transition
+	)
+
+	for subject := range previous {
+		subjects = append(subjects, subject)
+	}
+	for subject := range current {
+		if _, ok := previous[subject]; !ok {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		from, ok := previous[subject]
+		if !ok {
+			from = noState
+		}
+		to, ok := current[subject]
+		if !ok {
+			to = noState
+		}
+
+		if from != to {
+			transitions = append(transitions, Transition{
+				Time:    now,
+				Subject: subject,
+				From:    from,
+				To:      to,
+			})
+		}
+	}
+
+	return transitions
+}
+
+// writeTransitions prints one line per transition with its timestamp.
+func writeTransitions(w io.Writer, transitions []Transition) {
+	for _, transition := range transitions {
+		fmt.Fprintf(w, "%s %s\n", transition.Time.Format(time.RFC3339), transition)
+	}
+}
+
+// writeStates prints the states of the first check, which the later transitions start from.
+func writeStates(w io.Writer, states map[string]string, now time.Time) {
+	var (
+		subjects []string
+	)
+
+	for subject := range states {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		fmt.Fprintf(w, "%s %s %s\n", now.Format(time.RFC3339), subject, states[subject])
+	}
+}
+
+// writeTimeline prints every transition since start, relative to start.
+func writeTimeline(w io.Writer, start time.Time, initial string, transitions []Transition, end time.Time, final string) {
+	fmt.Fprintln(w, "8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
+	fmt.Fprintf(w, "Timeline:\n")
+	fmt.Fprintf(w, "%10s %s started, the cluster is %s\n", "+0s", start.Format(time.RFC3339), initial)
+	for _, transition := range transitions {
+		fmt.Fprintf(w, "%10s %s\n", "+"+transition.Time.Sub(start).Round(time.Second).String(), transition)
+	}
+	fmt.Fprintf(w, "%10s %s finished, the cluster is %s\n", "+"+end.Sub(start).Round(time.Second).String(), end.Format(time.RFC3339), final)
+}
@@ -16,10 +16,10 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
 )
@@ -84,25 +84,26 @@ func (vms *VMs) Run() error {
 	return nil
 }
 
-func (vms *VMs) ClusterStatus() {
+func (vms *VMs) ClusterStatus(ctx context.Context) CheckResult {
 	var (
-		ctx            context.Context
 		cancel         context.CancelFunc
 		connCompute    *gophercloud.ServiceClient
 		infraID        string
 		allServers     []servers.Server
 		server         servers.Server
 		allHypervisors []hypervisors.Hypervisor
+		clusterServers []servers.Server
+		flavor         *flavors.Flavor
+		findings       []Finding
 		err            error
 	)
 
-	ctx, cancel = vms.services.GetContextWithTimeout()
+	ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	connCompute, err = NewServiceClient(ctx, "compute", DefaultClientOpts(vms.services.GetCloud()))
 	if err != nil {
-		fmt.Printf("%s: Error: NewServiceClient returns error %v\n", VMsName, err)
-		return
+		return unknownCheckResult(VMsName, "NewServiceClient returns error %v", err)
 	}
 
 	infraID = vms.services.GetMetadata().GetInfraID()
@@ -110,24 +111,21 @@ func (vms *VMs) ClusterStatus() {
 
 	allServers, err = getAllServers(ctx, vms.services.GetCloud())
 	if err != nil {
-		fmt.Printf("%s: Error: getAllServers returns error %v\n", VMsName, err)
-		return
+		return unknownCheckResult(VMsName, "getAllServers returns error %v", err)
 	}
 
 	allHypervisors, err = getAllHypervisors(ctx, connCompute)
 	if err != nil {
-		fmt.Printf("%s: Error: getAllHypervisors returns error %v\n", VMsName, err)
-		return
+		return unknownCheckResult(VMsName, "getAllHypervisors returns error %v", err)
 	}
 
-	fmt.Println("8<--------8<--------8<--------8<--------8<--------8<--------8<--------8<--------")
-
 	for _, server = range allServers {
 		var (
-			macAddress         string
-			ipAddress          string
-			sshAlive           = "DEAD"
-			hypervisor         hypervisors.Hypervisor
+			macAddress string
+			ipAddress  string
+			sshAlive   = "DEAD"
+			host       string
+			finding    Finding
 		)
 
 		if !strings.HasPrefix(strings.ToLower(server.Name), infraID) {
@@ -135,10 +133,12 @@ func (vms *VMs) ClusterStatus() {
 			continue
 		}
 		log.Debugf("ClusterStatus: FOUND    server = %s", server.Name)
+		clusterServers = append(clusterServers, server)
 
 		macAddress, ipAddress, err = findIpAddress(server)
 		if err != nil {
 			log.Debugf("ClusterStatus: findIpAddress received error %v", err)
+			findings = append(findings, newFinding(server.Name, SeverityWarning, "%s has status (%s) and no IP address (%v)", server.Name, server.Status, err))
 			continue
 		}
 
@@ -147,37 +147,66 @@ func (vms *VMs) ClusterStatus() {
 			sshAlive = "ALIVE"
 		}
 
-		fmt.Printf("%s: %s has status (%s), power state (%s), MAC address (%s), IP address (%s), and ssh status (%s)\n",
-			VMsName,
+		host = server.HypervisorHostname
+		if host == "" {
+			host = "<unknown>"
+		}
+
+		finding = newFinding(server.Name, vmSeverity(server.Status, sshAlive), "%s has status (%s), power state (%s), MAC address (%s), IP address (%s), ssh status (%s), and host (%s)",
 			server.Name,
 			server.Status,
 			server.PowerState.String(),
 			macAddress,
 			ipAddress,
 			sshAlive,
+			host,
 		)
-		fmt.Println()
-
-		log.Debugf("ClusterStatus: server.HypervisorHostname = %s", server.HypervisorHostname)
-		hypervisor, err = findHypervisorverInList(allHypervisors, server.HypervisorHostname)
-		log.Debugf("ClusterStatus: hypervisor = %+v\n", hypervisor)
-		if err != nil {
-			log.Debugf("ClusterStatus: findHypervisorverInList received error %v\n", err)
-			continue
+		finding.Fields = map[string]string{
+			"status":     server.Status,
+			"powerState": server.PowerState.String(),
+			"macAddress": macAddress,
+			"ipAddress":  ipAddress,
+			"ssh":        sshAlive,
+			"host":       host,
 		}
+		findings = append(findings, finding)
 
-		if false {
-			fmt.Printf("%s: Console reached via: sshpass -p ${SSH_PASSWORD} ssh -t hscroot@%s mkvterm -m %s -p %s\n",
-				VMsName,
-				hypervisor.HostIP,
-				hypervisor.HypervisorHostname,
-				server.InstanceName,
-			)
-			fmt.Println()
-		}
 	}
+
+	if len(findings) == 0 {
+		findings = append(findings, newFinding(VMsName, SeverityError, "No servers found for %s", infraID))
+		return newCheckResult(findings)
+	}
+
+	flavor, err = clusterFlavor(ctx, vms.services.GetCloud(), connCompute, clusterServers)
+	if err != nil {
+		log.Debugf("ClusterStatus: clusterFlavor received error %v", err)
+		findings = append(findings, newFinding(VMsName, SeverityWarning, "Could not find the flavor of the cluster, so it is unknown whether another VM would fit (%v)", err))
+	}
+
+	findings = append(findings, placementFindings(clusterServers, allHypervisors, flavor)...)
+
+	return newCheckResult(findings)
+}
+
+// vmSeverity fails a VM which is in error or which is active but cannot be reached with ssh.  A
+// VM which is still being built is only a warning.
+func vmSeverity(status string, sshAlive string) Severity {
+	switch {
+	case status == "ERROR":
+		return SeverityError
+	case status != "ACTIVE":
+		return SeverityWarning
+	case sshAlive != "ALIVE":
+		return SeverityError
+	}
+	return SeverityInfo
 }
 
 func (vms *VMs) Priority() (int, error) {
 	return -1, nil
 }
+
+func (vms *VMs) Dependencies() []string {
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 IBM Corp
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	watchStateFilename = "watch-installation.json"
+	watchStateVersion  = 1
+)
+
+// watchState is what the watch-installation loop shares with the HTTP API.
+type watchState struct {
+	lock                sync.Mutex
+	bastionInformations []bastionInformation
+	lastReconcile       time.Time
+	reconcile           chan struct{}
+}
+
+func newWatchState() *watchState {
+	return &watchState{
+		bastionInformations: []bastionInformation{},
+		reconcile:           make(chan struct{}, 1),
+	}
+}
+
+// setBastionInformations keeps a copy, since the watch loop goes on updating its own slice.
+func (ws *watchState) setBastionInformations(bastionInformations []bastionInformation) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ws.bastionInformations = append([]bastionInformation{}, bastionInformations...)
+}
+
+func (ws *watchState) getBastionInformations() []bastionInformation {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	return append([]bastionInformation{}, ws.bastionInformations...)
+}
+
+func (ws *watchState) setLastReconcile(t time.Time) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ws.lastReconcile = t
+}
+
+func (ws *watchState) getLastReconcile() time.Time {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	return ws.lastReconcile
+}
+
+// requestReconcile wakes up the watch loop.  Requests made while one is pending are merged.
+func (ws *watchState) requestReconcile() {
+	select {
+	case ws.reconcile <- struct{}{}:
+	default:
+	}
+}
+
+// sleep waits for d or for a reconcile request, and returns whether a reconcile was requested.
+func (ws *watchState) sleep(d time.Duration) bool {
+	var timer = time.NewTimer(d)
+
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return false
+	case <-ws.reconcile:
+		return true
+	}
+}
+
+// savedWatchState is what watch-installation knew the last time it reconciled.  Loading it on
+// startup means servers which were created or deleted while the daemon was down are still
+// seen as added or deleted.
+type savedWatchState struct {
+	Version             int                  `json:"version"`
+	Saved               time.Time            `json:"saved"`
+	KnownServers        []string             `json:"knownServers"`
+	FirstDnsRun         bool                 `json:"firstDnsRun"`
+	BastionInformations []bastionInformation `json:"bastionInformations"`
+}
+
+// loadWatchState returns nil if nothing has been saved in stateDir yet.
+func loadWatchState(stateDir string) (*savedWatchState, error) {
+	var (
+		filename = filepath.Join(stateDir, watchStateFilename)
+		content  []byte
+		saved    savedWatchState
+		err      error
+	)
+
+	content, err = os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Debugf("loadWatchState: %s does not exist", filename)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not read %s: %v", filename, err)
+	}
+
+	err = json.Unmarshal(content, &saved)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Could not parse %s: %v", filename, err)
+	}
+	if saved.Version != watchStateVersion {
+		return nil, fmt.Errorf("Error: %s has version %d, expecting %d", filename, saved.Version, watchStateVersion)
+	}
+	log.Debugf("loadWatchState: %s saved at %v with %d servers", filename, saved.Saved, len(saved.KnownServers))
+
+	return &saved, nil
+}
+
+// saveWatchState replaces the state file through a rename so a crash never leaves half of one.
+func saveWatchState(stateDir string, knownServers sets.Set[string], bastionInformations []bastionInformation) error {
+	var (
+		filename = filepath.Join(stateDir, watchStateFilename)
+		saved    = savedWatchState{
+			Version:             watchStateVersion,
+			Saved:               time.Now(),
+			KnownServers:        sets.List(knownServers),
+			FirstDnsRun:         firstDnsRun,
+			BastionInformations: bastionInformations,
+		}
+		content []byte
+		file    *os.File
+		err     error
+	)
+
+	content, err = json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(stateDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err = os.CreateTemp(stateDir, "."+watchStateFilename+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error: Could not write %s: %v", file.Name(), err)
+	}
+
+	err = os.Rename(file.Name(), filename)
+	if err != nil {
+		return err
+	}
+	log.Debugf("saveWatchState: wrote %s with %d servers", filename, len(saved.KnownServers))
+
+	return nil
+}
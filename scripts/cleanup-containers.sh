@@ -35,18 +35,9 @@ done
 set -euo pipefail
 #set -x
 
-while read CONTAINER
-do
-	echo "CONTAINER=${CONTAINER}"
-	
-	while read OBJECT
-	do
-		echo "OBJECT=${OBJECT}"
-
-		openstack --os-cloud=${CLOUD} object delete ${CONTAINER} ${OBJECT}
-
-	done < <(openstack --os-cloud=${CLOUD} object list ${CONTAINER} --format csv | sed -e '/\(Name\)/d' -e 's,",,g')
-
-	openstack --os-cloud=${CLOUD} container delete ${CONTAINER} ${OBJECT}
-
-done < <(openstack --os-cloud=${CLOUD} container list --format csv | sed -e '/\(Name\|container_name\)/d' -e 's,",,g')
+# Only the <infraID>-ignition containers are considered.  Pass --dryRun true
+# to list them first, and --bastionMetadata to protect live clusters.
+PowerVC-Tool \
+	cleanup-containers \
+	--cloud "${CLOUD}" \
+	"$@"
@@ -88,3 +88,19 @@ then
 	echo "Error: openshift-install destroy cluster failed with an RC of ${RC}"
 	exit 1
 fi
+
+ARGS="delete-cluster \
+	--metadata ${CLUSTER_DIR}/metadata.json \
+	--shouldDebug true"
+if [[ -v BASEDOMAIN ]]
+then
+	ARGS+=" --domainName ${BASEDOMAIN}"
+fi
+
+PowerVC-Tool ${ARGS}
+RC=$?
+if [ ${RC} -gt 0 ]
+then
+	echo "Error: PowerVC-Tool delete-cluster failed with an RC of ${RC}"
+	exit 1
+fi